@@ -0,0 +1,188 @@
+package languageserver
+
+import (
+	"regexp"
+	"strings"
+
+	ortfodb "github.com/ortfo/db"
+)
+
+var (
+	languageMarkerPattern         = regexp.MustCompile(ortfodb.PatternLanguageMarker)
+	abbreviationDefinitionPattern = regexp.MustCompile(ortfodb.PatternAbbreviationDefinition)
+	// footnoteDefinitionPattern matches the first line of footnote definitions: [^name]: content
+	footnoteDefinitionPattern = regexp.MustCompile(`^\[\^([^\]]+)\]:\s*(.*)$`)
+	headingPattern            = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	// wholeLineMediaEmbed and wholeLineLink match lines that only contain a media embed or a link, which ortfodb turns into media and link blocks.
+	wholeLineMediaEmbed = regexp.MustCompile(`^\s*` + mediaEmbedPattern.String() + `\s*$`)
+	wholeLineLink       = regexp.MustCompile(`^\s*` + markdownLinkPattern.String() + `\s*$`)
+)
+
+// bodyBlockKind is the kind of a block of the body of a description file.
+type bodyBlockKind string
+
+const (
+	blockTitle        bodyBlockKind = "title"
+	blockParagraph    bodyBlockKind = "paragraph"
+	blockMedia        bodyBlockKind = "media"
+	blockLink         bodyBlockKind = "link"
+	blockFootnote     bodyBlockKind = "footnote"
+	blockAbbreviation bodyBlockKind = "abbreviation"
+)
+
+// bodyBlock is a block of the body of a description file, as ortfodb splits it.
+type bodyBlock struct {
+	Kind bodyBlockKind
+	// Start and End are the first and last lines of the block, 0-based and inclusive.
+	Start int
+	End   int
+	// Name is a short text identifying the block, and Detail additional information: source of media, URL of links, content of footnotes and abbreviations.
+	Name   string
+	Detail string
+	// Fenced is true for fenced code blocks, whose contents are not interpreted.
+	Fenced bool
+}
+
+// languageSection is the part of the body of a description file written in one language, starting with a `:: language` marker.
+type languageSection struct {
+	// Language is empty for the content before the first marker, which ortfodb shares between all languages.
+	Language string
+	// Marker is the line of the language marker, or -1 for the content before the first marker.
+	Marker int
+	// Start and End are the first and last lines of the section, 0-based and inclusive. Trailing blank lines are excluded.
+	Start  int
+	End    int
+	Blocks []bodyBlock
+}
+
+// LanguageSections splits the body of the description file into language sections and blocks.
+// This is a line-based approximation of what ortfodb does by rendering the markdown to HTML.
+// The content before the first marker is only included if it is not blank.
+func (d DescriptionFile) LanguageSections() []languageSection {
+	sections := make([]languageSection, 0)
+	current := languageSection{Marker: -1, Start: d.bodyStartsAt()}
+	var block *bodyBlock
+	inCodeBlock := false
+
+	closeBlock := func() {
+		if block == nil {
+			return
+		}
+		d.classifyBlock(block, current.Blocks)
+		current.Blocks = append(current.Blocks, *block)
+		block = nil
+	}
+	closeSection := func(end int) {
+		closeBlock()
+		current.End = current.Start
+		for line := end; line >= current.Start; line-- {
+			if strings.TrimSpace(d.lineAt(line)) != "" {
+				current.End = line
+				break
+			}
+		}
+		if current.Marker != -1 || len(current.Blocks) > 0 {
+			sections = append(sections, current)
+		}
+	}
+
+	for i := d.bodyStartsAt(); i < len(d.lines); i++ {
+		line := strings.TrimSuffix(d.lines[i], "\r")
+		switch {
+		case inCodeBlock:
+			block.End = i
+			if codeFence.MatchString(line) {
+				inCodeBlock = false
+				closeBlock()
+			}
+		case codeFence.MatchString(line):
+			closeBlock()
+			inCodeBlock = true
+			block = &bodyBlock{Kind: blockParagraph, Start: i, End: i, Name: strings.TrimSpace(line), Fenced: true}
+		case languageMarkerPattern.MatchString(line):
+			closeSection(i - 1)
+			current = languageSection{
+				Language: strings.TrimSpace(languageMarkerPattern.FindStringSubmatch(line)[1]),
+				Marker:   i,
+				Start:    i,
+			}
+		case strings.TrimSpace(line) == "":
+			closeBlock()
+		case block != nil && block.Kind == blockFootnote && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			block.End = i
+			block.Detail += " " + strings.TrimSpace(line)
+		case footnoteDefinitionPattern.MatchString(line):
+			closeBlock()
+			match := footnoteDefinitionPattern.FindStringSubmatch(line)
+			block = &bodyBlock{Kind: blockFootnote, Start: i, End: i, Name: match[1], Detail: match[2]}
+		case abbreviationDefinitionPattern.MatchString(line):
+			closeBlock()
+			match := abbreviationDefinitionPattern.FindStringSubmatch(line)
+			current.Blocks = append(current.Blocks, bodyBlock{Kind: blockAbbreviation, Start: i, End: i, Name: match[1], Detail: match[2]})
+		case headingPattern.MatchString(line):
+			closeBlock()
+			block = &bodyBlock{Kind: blockParagraph, Start: i, End: i}
+			closeBlock()
+		case block != nil:
+			block.End = i
+		default:
+			block = &bodyBlock{Kind: blockParagraph, Start: i, End: i}
+		}
+	}
+	closeSection(len(d.lines) - 1)
+	return sections
+}
+
+// classifyBlock sets the kind and name of a block that was parsed as a paragraph, given the blocks before it in the same section.
+func (d DescriptionFile) classifyBlock(block *bodyBlock, previous []bodyBlock) {
+	if block.Kind != blockParagraph || block.Name != "" {
+		return
+	}
+
+	first := strings.TrimSuffix(d.lineAt(block.Start), "\r")
+	block.Name = summarize(first)
+	if block.Start != block.End {
+		return
+	}
+
+	if match := wholeLineMediaEmbed.FindStringSubmatch(first); match != nil {
+		block.Kind = blockMedia
+		block.Name, block.Detail = match[2], match[3]
+		if parts := titleInAlt.FindStringSubmatch(block.Name); parts != nil {
+			block.Name = parts[1]
+		}
+		if block.Name == "" {
+			block.Name = match[3]
+		}
+		return
+	}
+
+	if match := wholeLineLink.FindStringSubmatch(first); match != nil {
+		block.Kind = blockLink
+		block.Name, block.Detail = match[1], match[2]
+		return
+	}
+
+	if match := headingPattern.FindStringSubmatch(first); match != nil {
+		block.Name = match[2]
+		if len(match[1]) > 1 {
+			return
+		}
+		for _, other := range previous {
+			if other.Kind == blockTitle {
+				return
+			}
+		}
+		block.Kind = blockTitle
+	}
+}
+
+// summarize shortens a line of markdown to be used as the name of a block.
+func summarize(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimLeft(line, "#>-*+ ")
+	if runes := []rune(line); len(runes) > 50 {
+		return string(runes[:50]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,99 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+const testBilingualDescription = `---
+wip: true
+---
+
+![Shared cover](cover.png)
+
+:: en
+
+# Project
+
+Some text
+on two lines.
+
+[Source code](https://example.com)
+
+` + "```go\n\nfunc main() {}\n```" + `
+
+:: fr
+
+# Projet
+
+Du texte[^1].
+
+[^1]: Une note
+    sur deux lignes.
+*[HTML]: HyperText Markup Language
+`
+
+func TestLanguageSections(t *testing.T) {
+	type block struct {
+		kind       bodyBlockKind
+		start, end int
+		name       string
+	}
+	expected := []struct {
+		language   string
+		start, end int
+		blocks     []block
+	}{
+		{"", 3, 4, []block{{blockMedia, 4, 4, "Shared cover"}}},
+		{"en", 6, 18, []block{
+			{blockTitle, 8, 8, "Project"},
+			{blockParagraph, 10, 11, "Some text"},
+			{blockLink, 13, 13, "Source code"},
+			{blockParagraph, 15, 18, "```go"},
+		}},
+		{"fr", 20, 28, []block{
+			{blockTitle, 22, 22, "Projet"},
+			{blockParagraph, 24, 24, "Du texte[^1]."},
+			{blockFootnote, 26, 27, "1"},
+			{blockAbbreviation, 28, 28, "HTML"},
+		}},
+	}
+
+	sections := ParseDescriptionFile(testBilingualDescription, protocol.Position{}).LanguageSections()
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %d: %+v", len(expected), len(sections), sections)
+	}
+
+	for i, e := range expected {
+		section := sections[i]
+		if section.Language != e.language || section.Start != e.start || section.End != e.end || len(section.Blocks) != len(e.blocks) {
+			t.Errorf("section %d: expected %q on lines %d-%d with %d blocks, got %q on lines %d-%d with %d blocks: %+v", i, e.language, e.start, e.end, len(e.blocks), section.Language, section.Start, section.End, len(section.Blocks), section.Blocks)
+			continue
+		}
+		for j, b := range e.blocks {
+			got := section.Blocks[j]
+			if got.Kind != b.kind || got.Start != b.start || got.End != b.end || got.Name != b.name {
+				t.Errorf("section %q, block %d: expected %s %q on lines %d-%d, got %s %q on lines %d-%d", e.language, j, b.kind, b.name, b.start, b.end, got.Kind, got.Name, got.Start, got.End)
+			}
+		}
+	}
+}
+
+func TestFoldingRanges(t *testing.T) {
+	for description, expected := range map[string][][2]uint32{
+		testBilingualDescription: {{0, 2}, {6, 18}, {20, 28}, {26, 28}},
+		testDescription:          {{0, 12}, {5, 7}, {8, 11}},
+	} {
+		ranges := ParseDescriptionFile(description, protocol.Position{}).FoldingRanges()
+		if len(ranges) != len(expected) {
+			t.Errorf("expected %d folding ranges, got %d: %+v", len(expected), len(ranges), ranges)
+			continue
+		}
+		for i, e := range expected {
+			if ranges[i].StartLine != e[0] || ranges[i].EndLine != e[1] {
+				t.Errorf("expected lines %d-%d, got %d-%d", e[0], e[1], ranges[i].StartLine, ranges[i].EndLine)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package languageserver
+
+import (
+	"fmt"
+
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// Values of the aliases.canonicalName setting.
+const (
+	// canonicalURLFriendlyName rewrites references to the URL-friendly name of entries: the slug of technologies, the slugified plural of tags.
+	canonicalURLFriendlyName = "urlFriendlyName"
+	// canonicalDisplayName rewrites references to the display name of entries: the name of technologies, the plural of tags.
+	canonicalDisplayName = "displayName"
+	// canonicalOff disables the diagnostics and code actions about canonical names.
+	canonicalOff = "off"
+)
+
+// nonCanonicalReference is a tag or technology of the frontmatter that refers to an entry of its repository by another name than the canonical one.
+type nonCanonicalReference struct {
+	kind      string
+	node      *yaml.Node
+	canonical string
+}
+
+// canonicalNameOf returns the name item should be referred to by, according to setting.
+func canonicalNameOf(item referrable, setting string) string {
+	if setting == canonicalDisplayName {
+		return item.DisplayName()
+	}
+	return item.URLFriendlyName()
+}
+
+// NonCanonicalReferences returns the tags and technologies of the description file that do not use the canonical name of the entry they refer to.
+// Unknown tags and technologies are not included.
+func (d DescriptionFile) NonCanonicalReferences(s state, setting string) []nonCanonicalReference {
+	references := make([]nonCanonicalReference, 0)
+	if setting == canonicalOff {
+		return references
+	}
+
+	for _, kind := range []string{"tag", "technology"} {
+		sequence, ok := d.frontmatterMappings[frontmatterKeyOf(kind)]
+		if !ok || sequence.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		repo := s.tags
+		if kind == "technology" {
+			repo = s.technologies
+		}
+
+		for _, item := range sequence.Content {
+			if item.Kind != yaml.ScalarNode || item.Value == "" {
+				continue
+			}
+
+			for i := range repo {
+				entry, err := decodeReferrable(kind, &repo[i])
+				if err != nil || !entry.ReferredToBy(item.Value) {
+					continue
+				}
+				if canonical := canonicalNameOf(entry, setting); canonical != "" && canonical != item.Value {
+					references = append(references, nonCanonicalReference{kind: kind, node: item, canonical: canonical})
+				}
+				break
+			}
+		}
+	}
+	return references
+}
+
+// CanonicalNameDiagnostics reports tags and technologies that are not referred to by their canonical name.
+func (d DescriptionFile) CanonicalNameDiagnostics(s state, setting string) []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	for _, reference := range d.NonCanonicalReferences(s, setting) {
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Range:    nodeRange(reference.node),
+			Severity: protocol.DiagnosticSeverityInformation,
+			Code:     "non-canonical-" + reference.kind,
+			Source:   diagnosticsSource,
+			Message:  fmt.Sprintf("%s %q is canonically named %q", reference.kind, reference.node.Value, reference.canonical),
+		})
+	}
+	return diagnostics
+}
+
+// canonicalNameEdits returns the edits that rewrite every reference to its canonical name.
+func canonicalNameEdits(references []nonCanonicalReference) []protocol.TextEdit {
+	edits := make([]protocol.TextEdit, 0, len(references))
+	for _, reference := range references {
+		edits = append(edits, protocol.TextEdit{Range: nodeRange(reference.node), NewText: yamlScalar(reference.canonical)})
+	}
+	return edits
+}
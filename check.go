@@ -0,0 +1,263 @@
+package languageserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+// Formats of check reports.
+const (
+	FormatHuman = "human"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// CheckedFile holds the problems found in a file of the portfolio, sorted by position.
+type CheckedFile struct {
+	Path        string
+	Diagnostics []protocol.Diagnostic
+}
+
+// Check loads the portfolio configured at configurationPath, and returns the problems found in its tags and technologies repositories and in the description file of every project, with the default settings.
+// Files without problems are included, with no diagnostics.
+// If the configuration or one of the repositories cannot be loaded, the problem that prevented it is the only one returned.
+func Check(log *zap.Logger, configurationPath string) ([]CheckedFile, error) {
+	logger = log
+	workspace, err := NewWorkspace(log, configurationPath)
+	var loadErr *loadError
+	if errors.As(err, &loadErr) {
+		// the file that prevented the portfolio from being loaded is the only one checked
+		contents, _ := os.ReadFile(loadErr.path)
+		return []CheckedFile{checkedFile(loadErr.path, []protocol.Diagnostic{loadErr.diagnostic(string(contents))})}, nil
+	}
+	if err != nil {
+		return []CheckedFile{}, err
+	}
+
+	current := workspace.StateOf(uri.File(configurationPath))
+	checked := make([]CheckedFile, 0)
+	for _, kind := range []string{"tag", "technology"} {
+		path := repositoryPath(current.config, kind)
+		contents, err := workspace.Contents(uri.File(path))
+		if err != nil {
+			return checked, fmt.Errorf("while reading %s repository: %w", kind, err)
+		}
+		checked = append(checked, checkedFile(path, ParseRepositoryFile(kind, contents, protocol.Position{}).Diagnostics()))
+	}
+
+	paths, err := ProjectDescriptionFiles(current.config)
+	if err != nil {
+		return checked, fmt.Errorf("while listing description files: %w", err)
+	}
+
+	for _, path := range paths {
+		contents, err := workspace.Contents(uri.File(path))
+		if err != nil {
+			return checked, fmt.Errorf("while reading description file: %w", err)
+		}
+		file := ParseDescriptionFile(contents, protocol.Position{})
+		checked = append(checked, checkedFile(path, file.Diagnostics(current, workspace.Settings(), filepath.Dir(path))))
+	}
+	return checked, nil
+}
+
+func checkedFile(path string, diagnostics []protocol.Diagnostic) CheckedFile {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return comparePositions(diagnostics[i].Range.Start, diagnostics[j].Range.Start) < 0
+	})
+	return CheckedFile{Path: path, Diagnostics: diagnostics}
+}
+
+// Severities problems can be counted from, by their name in reports.
+var countedSeverities = map[string]protocol.DiagnosticSeverity{
+	"error":   protocol.DiagnosticSeverityError,
+	"warning": protocol.DiagnosticSeverityWarning,
+	"note":    protocol.DiagnosticSeverityHint,
+}
+
+// ParseSeverity returns the severity named error, warning or note, as written in human reports.
+// Counting problems from note counts every problem.
+func ParseSeverity(name string) (protocol.DiagnosticSeverity, error) {
+	severity, ok := countedSeverities[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown severity %q, should be one of error, warning or note", name)
+	}
+	return severity, nil
+}
+
+// CountProblems returns the number of diagnostics of checked files that are at least as severe as atLeast.
+func CountProblems(checked []CheckedFile, atLeast protocol.DiagnosticSeverity) int {
+	problems := 0
+	for _, file := range checked {
+		for _, diagnostic := range file.Diagnostics {
+			// more severe diagnostics have lower severities
+			if diagnostic.Severity <= atLeast {
+				problems++
+			}
+		}
+	}
+	return problems
+}
+
+// WriteReport writes the problems found in checked files to w, in the given format.
+// Paths inside of base are written relative to it.
+func WriteReport(w io.Writer, checked []CheckedFile, format string, base string) error {
+	switch format {
+	case FormatHuman:
+		return writeHumanReport(w, checked, base)
+	case FormatJSON:
+		return writeJSONReport(w, checked, base)
+	case FormatSARIF:
+		return writeSARIFReport(w, checked, base)
+	}
+	return fmt.Errorf("unknown report format %q, should be one of %s, %s or %s", format, FormatHuman, FormatJSON, FormatSARIF)
+}
+
+// reportedPath returns path relative to base if it is inside of it, with forward slashes.
+func reportedPath(path string, base string) string {
+	if relative, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(relative, "..") {
+		path = relative
+	}
+	return filepath.ToSlash(path)
+}
+
+// writeHumanReport writes one line per problem, as path:line:column: severity: message (code), followed by a summary.
+func writeHumanReport(w io.Writer, checked []CheckedFile, base string) error {
+	counts := make(map[protocol.DiagnosticSeverity]int)
+	for _, file := range checked {
+		for _, diagnostic := range file.Diagnostics {
+			counts[diagnostic.Severity]++
+			_, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s (%v)\n",
+				reportedPath(file.Path, base),
+				diagnostic.Range.Start.Line+1,
+				diagnostic.Range.Start.Character+1,
+				strings.ToLower(diagnostic.Severity.String()),
+				diagnostic.Message,
+				diagnostic.Code,
+			)
+			if err != nil {
+				return fmt.Errorf("while writing report: %w", err)
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s, %s, %s in %s\n",
+		countOf(counts[protocol.DiagnosticSeverityError], "error"),
+		countOf(counts[protocol.DiagnosticSeverityWarning], "warning"),
+		countOf(counts[protocol.DiagnosticSeverityInformation]+counts[protocol.DiagnosticSeverityHint], "note"),
+		countOf(len(checked), "file"),
+	)
+	if err != nil {
+		return fmt.Errorf("while writing report: %w", err)
+	}
+	return nil
+}
+
+// reportedProblem is a problem as written in JSON reports. Lines and columns are 1-based, columns count UTF-16 code units.
+type reportedProblem struct {
+	Path      string `json:"path"`
+	Line      uint32 `json:"line"`
+	Column    uint32 `json:"column"`
+	EndLine   uint32 `json:"endLine"`
+	EndColumn uint32 `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+func writeJSONReport(w io.Writer, checked []CheckedFile, base string) error {
+	problems := make([]reportedProblem, 0)
+	for _, file := range checked {
+		for _, diagnostic := range file.Diagnostics {
+			problems = append(problems, reportedProblem{
+				Path:      reportedPath(file.Path, base),
+				Line:      diagnostic.Range.Start.Line + 1,
+				Column:    diagnostic.Range.Start.Character + 1,
+				EndLine:   diagnostic.Range.End.Line + 1,
+				EndColumn: diagnostic.Range.End.Character + 1,
+				Severity:  strings.ToLower(diagnostic.Severity.String()),
+				Code:      fmt.Sprint(diagnostic.Code),
+				Message:   diagnostic.Message,
+			})
+		}
+	}
+	return writeJSON(w, problems)
+}
+
+// sarifLevels maps severities of diagnostics to levels of SARIF results.
+var sarifLevels = map[protocol.DiagnosticSeverity]string{
+	protocol.DiagnosticSeverityError:       "error",
+	protocol.DiagnosticSeverityWarning:     "warning",
+	protocol.DiagnosticSeverityInformation: "note",
+	protocol.DiagnosticSeverityHint:        "note",
+}
+
+// writeSARIFReport writes a SARIF 2.1.0 log, as understood by code scanning tools such as GitHub's.
+func writeSARIFReport(w io.Writer, checked []CheckedFile, base string) error {
+	type object = map[string]interface{}
+	rules := make([]object, 0)
+	seenRules := make(map[string]bool)
+	results := make([]object, 0)
+	for _, file := range checked {
+		for _, diagnostic := range file.Diagnostics {
+			code := fmt.Sprint(diagnostic.Code)
+			if !seenRules[code] {
+				seenRules[code] = true
+				rules = append(rules, object{"id": code})
+			}
+
+			results = append(results, object{
+				"ruleId":  code,
+				"level":   sarifLevels[diagnostic.Severity],
+				"message": object{"text": diagnostic.Message},
+				"locations": []object{{
+					"physicalLocation": object{
+						"artifactLocation": object{"uri": reportedPath(file.Path, base)},
+						"region": object{
+							"startLine":   diagnostic.Range.Start.Line + 1,
+							"startColumn": diagnostic.Range.Start.Character + 1,
+							"endLine":     diagnostic.Range.End.Line + 1,
+							"endColumn":   diagnostic.Range.End.Character + 1,
+						},
+					},
+				}},
+			})
+		}
+	}
+
+	return writeJSON(w, object{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []object{{
+			"tool": object{
+				"driver": object{
+					"name":           "ortfols",
+					"version":        Version(),
+					"informationUri": "https://github.com/ortfo/languageserver",
+					"rules":          rules,
+				},
+			},
+			"columnKind": "utf16CodeUnits",
+			"results":    results,
+		}},
+	})
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("while writing report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package languageserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestWriteReport(t *testing.T) {
+	checked := []CheckedFile{
+		{Path: "/portfolio/tags.yaml"},
+		{Path: "/portfolio/projects/alpha/description.md", Diagnostics: []protocol.Diagnostic{{
+			Range:    protocol.Range{Start: protocol.Position{Line: 1, Character: 7}, End: protocol.Position{Line: 1, Character: 10}},
+			Severity: protocol.DiagnosticSeverityError,
+			Code:     "unknown-tag",
+			Message:  `tag "web" does not exist`,
+		}}},
+	}
+
+	var human bytes.Buffer
+	if err := WriteReport(&human, checked, FormatHuman, "/portfolio"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "projects/alpha/description.md:2:8: error: tag \"web\" does not exist (unknown-tag)\n1 error, no warnings, no notes in 2 files\n"
+	if human.String() != expected {
+		t.Errorf("expected human report %q, got %q", expected, human.String())
+	}
+
+	var sarif bytes.Buffer
+	if err := WriteReport(&sarif, checked, FormatSARIF, "/portfolio"); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	result := log.Runs[0].Results[0]
+	location := result.Locations[0].PhysicalLocation
+	if result.RuleID != "unknown-tag" || result.Level != "error" || location.ArtifactLocation.URI != "projects/alpha/description.md" || location.Region.StartLine != 2 || location.Region.StartColumn != 8 {
+		t.Errorf("unexpected SARIF result: %+v", result)
+	}
+
+	if err := WriteReport(&bytes.Buffer{}, checked, "xml", "/portfolio"); err == nil {
+		t.Errorf("expected an error for unknown formats")
+	}
+}
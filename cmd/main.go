@@ -1,23 +1,210 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ortfo/languageserver"
+	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 )
 
+const usage = `ortfols is the language server for ortfo portfolios.
+
+Usage:
+  ortfols [flags] [lsp]     start the language server (the default)
+  ortfols [flags] check     report problems in the repositories and every project, then exit
+                            with status 1 if there are problems as severe as --fail-on
+  ortfols version           print the version and exit
+
+Flags:
+`
+
 func main() {
-	os.MkdirAll("./logs", os.ModePerm)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// options are the subcommand and flags ortfols is run with.
+type options struct {
+	command    string
+	configPath string
+	logFile    string
+	logLevel   string
+	traceDir   string
+	stdio      bool
+	listen     string
+	socket     string
+	format     string
+	failOn     string
+}
+
+// parseArguments returns the options given by the command-line arguments.
+// The usage is written to stderr when they are invalid, and ok is false then.
+func parseArguments(args []string, stderr io.Writer) (opts options, ok bool) {
+	flags := flag.NewFlagSet("ortfols", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&opts.configPath, "config", "ortfodb.yaml", "path to the ortfodb.yaml configuration `file`")
+	flags.StringVar(&opts.configPath, "c", "ortfodb.yaml", "shorthand for --config")
+	flags.StringVar(&opts.logFile, "log-file", "", "write logs to `file` instead of the standard error output")
+	flags.StringVar(&opts.logLevel, "log-level", "info", "minimum `level` of logged messages: debug, info, warn or error")
+	flags.StringVar(&opts.traceDir, "trace-dir", "", "log raw requests and responses exchanged with the client in `directory`")
+	flags.BoolVar(&opts.stdio, "stdio", false, "talk to the client over the standard input and output (the default)")
+	flags.StringVar(&opts.listen, "listen", "", "listen for clients on `tcp:host:port` instead")
+	flags.StringVar(&opts.socket, "socket", "", "listen for clients on the unix socket at `path` instead")
+	flags.StringVar(&opts.format, "format", languageserver.FormatHuman, "`format` of the problems reported by check: human, json or sarif")
+	flags.StringVar(&opts.failOn, "fail-on", "error", "make check exit with status 1 on problems at least as severe as `severity`: error, warning or note")
+
+	// flags can be given before and after the subcommand
+	if err := flags.Parse(args); err != nil {
+		return opts, false
+	}
+	opts.command = "lsp"
+	arguments := flags.Args()
+	if len(arguments) > 0 && (arguments[0] == "lsp" || arguments[0] == "check" || arguments[0] == "version") {
+		opts.command = arguments[0]
+		if err := flags.Parse(arguments[1:]); err != nil {
+			return opts, false
+		}
+		arguments = flags.Args()
+	}
+
+	configSet := false
+	flags.Visit(func(f *flag.Flag) {
+		configSet = configSet || f.Name == "config" || f.Name == "c"
+	})
+	switch {
+	case len(arguments) == 1 && !configSet:
+		// the configuration path used to be given as the only argument
+		opts.configPath = arguments[0]
+	case len(arguments) > 0:
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n\n", strings.Join(arguments, " "))
+		flags.Usage()
+		return opts, false
+	}
+	return opts, true
+}
+
+// run runs ortfols with the given command-line arguments, and returns its exit code.
+// Deferred calls have all run when it returns, so that the caller can exit right away.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, ok := parseArguments(args, stderr)
+	if !ok {
+		return 2
+	}
+
+	if opts.command == "version" {
+		fmt.Fprintf(stdout, "ortfols %s\n", languageserver.Version())
+		return 0
+	}
+
+	logger, err := newLogger(opts.logFile, opts.logLevel)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not set up logging: %s\n", err)
+		return 2
+	}
+	defer logger.Sync()
+
+	if opts.command == "check" {
+		failureSeverity, err := languageserver.ParseSeverity(opts.failOn)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid --fail-on: %s\n", err)
+			return 2
+		}
+		return check(logger, opts.configPath, opts.format, failureSeverity, stdout, stderr)
+	}
+
+	transport, err := transportOf(opts.stdio, opts.listen, opts.socket)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if opts.traceDir != "" {
+		if err := os.MkdirAll(opts.traceDir, os.ModePerm); err != nil {
+			fmt.Fprintf(stderr, "could not create trace directory: %s\n", err)
+			return 2
+		}
+	}
+
+	if err := languageserver.StartServer(logger, opts.configPath, transport, opts.traceDir); err != nil {
+		logger.Error("server stopped", zap.Error(err))
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// newLogger returns a logger writing messages of at least the given level to path, or to the standard error output if path is empty.
+// The standard output is left alone, since it is used to talk to the client.
+func newLogger(path string, level string) (*zap.Logger, error) {
+	atomicLevel, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
 	logconf := zap.NewDevelopmentConfig()
-	logconf.OutputPaths = []string{"./logs/server.log"}
-	logger, _ := logconf.Build()
+	logconf.Level = atomicLevel
+	logconf.OutputPaths = []string{"stderr"}
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("while creating directory of log file: %w", err)
+		}
+		logconf.OutputPaths = []string{path}
+	}
+	logconf.ErrorOutputPaths = logconf.OutputPaths
+	return logconf.Build()
+}
+
+// transportOf returns the transport chosen with the --stdio, --listen and --socket flags.
+func transportOf(stdio bool, listen string, socket string) (languageserver.Transport, error) {
+	chosen := 0
+	for _, set := range []bool{stdio, listen != "", socket != ""} {
+		if set {
+			chosen++
+		}
+	}
+	if chosen > 1 {
+		return languageserver.Transport{}, fmt.Errorf("choose only one of --stdio, --listen and --socket")
+	}
 
-	if len(os.Args) < 2 || os.Args[1] == "" {
-		fmt.Println("set the path to the ortfodb.yaml path as the first argument")
-		os.Exit(1)
+	switch {
+	case listen != "":
+		address, ok := strings.CutPrefix(listen, "tcp:")
+		if !ok || address == "" {
+			return languageserver.Transport{}, fmt.Errorf("--listen should be of the form tcp:host:port, got %q", listen)
+		}
+		return languageserver.Transport{Kind: languageserver.TransportTCP, Address: address}, nil
+	case socket != "":
+		return languageserver.Transport{Kind: languageserver.TransportSocket, Address: socket}, nil
 	}
+	return languageserver.Transport{Kind: languageserver.TransportStdio}, nil
+}
 
-	languageserver.StartServer(logger, os.Args[1], "./logs/")
+// check prints the problems found in the portfolio to stdout, and returns the exit code: 1 if there are problems at least as severe as failureSeverity, 2 if the portfolio could not be checked.
+func check(logger *zap.Logger, configPath string, format string, failureSeverity protocol.DiagnosticSeverity, stdout io.Writer, stderr io.Writer) int {
+	checked, err := languageserver.Check(logger, configPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	workingDirectory, _ := os.Getwd()
+	if err := languageserver.WriteReport(stdout, checked, format, workingDirectory); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if languageserver.CountProblems(checked, failureSeverity) > 0 {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ortfo/languageserver"
+)
+
+func TestCheckExitCode(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"tags.yaml":                    "- singular: site\n  plural: sites\n",
+		"technologies.yaml":            "- slug: go\n  name: Go\n",
+		"projects/app/description.md":  "---\ntags: [web]\n---\n",
+		"projects/site/description.md": "---\ntags: [site]\nmade with: [go]\n---\n",
+	}
+	files["ortfodb.yaml"] = fmt.Sprintf("projects at: %s\nscattered mode folder: .ortfo\ntags:\n  repository: %s\ntechnologies:\n  repository: %s\n",
+		filepath.Join(root, "projects"), filepath.Join(root, "tags.yaml"), filepath.Join(root, "technologies.yaml"))
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(root, "ortfodb.yaml")
+
+	for _, test := range []struct {
+		args     []string
+		expected int
+	}{
+		// the unknown tag is only a warning
+		{[]string{"check", "--config", configPath}, 0},
+		{[]string{"check", "--config", configPath, "--fail-on", "error"}, 0},
+		{[]string{"--fail-on", "warning", "check", "--config", configPath}, 1},
+		{[]string{"check", "--config", configPath, "--fail-on", "note"}, 1},
+		{[]string{"check", "--config", configPath, "--fail-on", "fatal"}, 2},
+		{[]string{"check", "--config", configPath, "--format", "xml"}, 2},
+	} {
+		var stdout, stderr bytes.Buffer
+		code := run(append(test.args, "--log-level", "error"), &stdout, &stderr)
+		if code != test.expected {
+			t.Errorf("ortfols %s: expected exit code %d, got %d\nstdout: %s\nstderr: %s", strings.Join(test.args, " "), test.expected, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestParseArguments(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected options
+		ok       bool
+	}{
+		{nil, options{command: "lsp", configPath: "ortfodb.yaml"}, true},
+		{[]string{"check"}, options{command: "check", configPath: "ortfodb.yaml"}, true},
+		// flags can be given before and after the subcommand
+		{[]string{"--format", "json", "check", "-c", "portfolio.yaml"}, options{command: "check", configPath: "portfolio.yaml", format: "json"}, true},
+		{[]string{"lsp", "--listen", "tcp:localhost:7000"}, options{command: "lsp", configPath: "ortfodb.yaml", listen: "tcp:localhost:7000"}, true},
+		// the configuration path used to be given as the only argument
+		{[]string{"portfolio.yaml"}, options{command: "lsp", configPath: "portfolio.yaml"}, true},
+		{[]string{"version"}, options{command: "version", configPath: "ortfodb.yaml"}, true},
+		{[]string{"--config", "a.yaml", "b.yaml"}, options{}, false},
+		{[]string{"check", "a.yaml", "b.yaml"}, options{}, false},
+		{[]string{"--unknown"}, options{}, false},
+	} {
+		var stderr bytes.Buffer
+		opts, ok := parseArguments(test.args, &stderr)
+		if ok != test.ok {
+			t.Errorf("ortfols %s: expected ok to be %v, got %v (%s)", strings.Join(test.args, " "), test.ok, ok, stderr.String())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if opts.command != test.expected.command || opts.configPath != test.expected.configPath || opts.listen != test.expected.listen {
+			t.Errorf("ortfols %s: expected %+v, got %+v", strings.Join(test.args, " "), test.expected, opts)
+		}
+		if test.expected.format != "" && opts.format != test.expected.format {
+			t.Errorf("ortfols %s: expected format %q, got %q", strings.Join(test.args, " "), test.expected.format, opts.format)
+		}
+	}
+}
+
+func TestTransportOf(t *testing.T) {
+	for _, test := range []struct {
+		stdio    bool
+		listen   string
+		socket   string
+		expected languageserver.Transport
+		fails    bool
+	}{
+		{expected: languageserver.Transport{Kind: languageserver.TransportStdio}},
+		{stdio: true, expected: languageserver.Transport{Kind: languageserver.TransportStdio}},
+		{listen: "tcp:localhost:7000", expected: languageserver.Transport{Kind: languageserver.TransportTCP, Address: "localhost:7000"}},
+		{listen: "tcp::7000", expected: languageserver.Transport{Kind: languageserver.TransportTCP, Address: ":7000"}},
+		{socket: "/tmp/ortfols.sock", expected: languageserver.Transport{Kind: languageserver.TransportSocket, Address: "/tmp/ortfols.sock"}},
+		{listen: "localhost:7000", fails: true},
+		{listen: "tcp:", fails: true},
+		{stdio: true, socket: "/tmp/ortfols.sock", fails: true},
+		{listen: "tcp:localhost:7000", socket: "/tmp/ortfols.sock", fails: true},
+	} {
+		transport, err := transportOf(test.stdio, test.listen, test.socket)
+		if test.fails {
+			if err == nil {
+				t.Errorf("transportOf(%v, %q, %q): expected an error, got %v", test.stdio, test.listen, test.socket, transport)
+			}
+			continue
+		}
+		if err != nil || transport != test.expected {
+			t.Errorf("transportOf(%v, %q, %q): expected %v, got %v (%v)", test.stdio, test.listen, test.socket, test.expected, transport, err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package languageserver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// sourceFixAll is the kind of code actions that fix every problem of a file that can be fixed safely, which editors can run on save.
+const sourceFixAll protocol.CodeActionKind = "source.fixAll"
+
+// unknownReferrableFixes returns quick fixes for the tags and technologies of file that are not in their repository, and whose range overlaps at:
+// adding a skeleton entry for them at the end of the repository file, and replacing them with the closest existing name.
+// diagnostics are the diagnostics sent by the client, to tell it which ones the fixes resolve.
+// There are none while the portfolio is degraded: adding entries to a repository that is outdated could duplicate them.
+func (h Handler) unknownReferrableFixes(documentURI protocol.URI, file DescriptionFile, at protocol.Range, diagnostics []protocol.Diagnostic) ([]protocol.CodeAction, error) {
+	current := h.stateOf(documentURI)
+	actions := make([]protocol.CodeAction, 0)
+	if current.degraded {
+		return actions, nil
+	}
+	for _, kind := range []string{"tag", "technology"} {
+		sequence, ok := file.frontmatterMappings[frontmatterKeyOf(kind)]
+		if !ok || sequence.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		repo := current.tags
+		if kind == "technology" {
+			repo = current.technologies
+		}
+
+		for _, item := range sequence.Content {
+			if item.Kind != yaml.ScalarNode || item.Value == "" || !rangesOverlap(nodeRange(item), at) || isInRepository(kind, item.Value, repo) {
+				continue
+			}
+			resolved := diagnosticsAt(diagnostics, "unknown-"+kind, nodeRange(item))
+
+			if closest, ok := closestInRepository(kind, item.Value, repo); ok {
+				actions = append(actions, protocol.CodeAction{
+					Title:       fmt.Sprintf("Replace with %q", closest),
+					Kind:        protocol.QuickFix,
+					Diagnostics: resolved,
+					IsPreferred: true,
+					Edit: &protocol.WorkspaceEdit{
+						Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+							documentURI: {{Range: nodeRange(item), NewText: yamlScalar(closest)}},
+						},
+					},
+				})
+			}
+
+			repository := uri.File(repositoryPath(current.config, kind))
+			contents, err := h.Workspace.Contents(repository)
+			if err != nil {
+				h.Logger.Debug("unknownReferrableFixes:cannot read repository", zap.String("kind", kind), zap.Error(err))
+				continue
+			}
+
+			edit, err := appendRepositoryEntry(contents, skeletonEntry(kind, item.Value))
+			if err != nil {
+				h.Logger.Debug("unknownReferrableFixes:cannot add entry to repository", zap.String("kind", kind), zap.Error(err))
+				continue
+			}
+
+			actions = append(actions, protocol.CodeAction{
+				Title:       fmt.Sprintf("Add %s %q to %s", kind, item.Value, filepath.Base(repository.Filename())),
+				Kind:        protocol.QuickFix,
+				Diagnostics: resolved,
+				Edit: &protocol.WorkspaceEdit{
+					Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+						repository: {edit},
+					},
+				},
+			})
+		}
+	}
+	return actions, nil
+}
+
+// canonicalNameFixes returns code actions that rewrite tags and technologies to their canonical name: the ones whose range overlaps at, every one of the file, and every one of every project of the portfolio.
+// The file-wide action is also returned as a source.fixAll action when the client asks for those.
+func (h Handler) canonicalNameFixes(documentURI protocol.URI, file DescriptionFile, at protocol.Range, context protocol.CodeActionContext) ([]protocol.CodeAction, error) {
+	current := h.stateOf(documentURI)
+	setting := h.Workspace.Settings().canonicalName()
+	actions := make([]protocol.CodeAction, 0)
+	references := file.NonCanonicalReferences(current, setting)
+	if len(references) == 0 {
+		return actions, nil
+	}
+
+	resolved := make([]protocol.Diagnostic, 0)
+	for _, reference := range references {
+		if !rangesOverlap(nodeRange(reference.node), at) {
+			continue
+		}
+
+		fixed := diagnosticsAt(context.Diagnostics, "non-canonical-"+reference.kind, nodeRange(reference.node))
+		resolved = append(resolved, fixed...)
+		actions = append(actions, protocol.CodeAction{
+			Title:       fmt.Sprintf("Replace %q with %q", reference.node.Value, reference.canonical),
+			Kind:        protocol.QuickFix,
+			Diagnostics: fixed,
+			IsPreferred: true,
+			Edit: &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+					documentURI: canonicalNameEdits([]nonCanonicalReference{reference}),
+				},
+			},
+		})
+	}
+	underCursor := len(actions) > 0
+
+	fileEdit := &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			documentURI: canonicalNameEdits(references),
+		},
+	}
+	if underCursor && len(references) > 1 {
+		actions = append(actions, protocol.CodeAction{
+			Title:       "Use canonical names for all tags and technologies of this file",
+			Kind:        protocol.QuickFix,
+			Diagnostics: resolved,
+			Edit:        fileEdit,
+		})
+	}
+	if requested(context.Only, sourceFixAll) {
+		actions = append(actions, protocol.CodeAction{
+			Title: "Use canonical names for all tags and technologies",
+			Kind:  sourceFixAll,
+			Edit:  fileEdit,
+		})
+	}
+
+	if underCursor {
+		portfolioEdit, err := h.portfolioCanonicalNameEdits(current, setting)
+		if err != nil {
+			return actions, fmt.Errorf("while rewriting references of the portfolio: %w", err)
+		}
+		portfolioEdit.Changes[documentURI] = fileEdit.Changes[documentURI]
+		if len(portfolioEdit.Changes) > 1 {
+			actions = append(actions, protocol.CodeAction{
+				Title: "Use canonical names for all tags and technologies of every project",
+				Kind:  protocol.QuickFix,
+				Edit:  portfolioEdit,
+			})
+		}
+	}
+	return actions, nil
+}
+
+// portfolioCanonicalNameEdits returns the edits that rewrite the tags and technologies of the description file of every project of the portfolio whose state is s to their canonical name.
+func (h Handler) portfolioCanonicalNameEdits(s state, setting string) (*protocol.WorkspaceEdit, error) {
+	paths, err := ProjectDescriptionFiles(s.config)
+	if err != nil {
+		return nil, fmt.Errorf("while listing description files: %w", err)
+	}
+
+	changes := make(map[protocol.DocumentURI][]protocol.TextEdit)
+	for _, path := range paths {
+		at := uri.File(path)
+		contents, err := h.Workspace.Contents(at)
+		if err != nil {
+			h.Logger.Debug("portfolioCanonicalNameEdits:skipping unreadable description file", zap.String("path", path), zap.Error(err))
+			continue
+		}
+
+		if references := ParseDescriptionFile(contents, protocol.Position{}).NonCanonicalReferences(s, setting); len(references) > 0 {
+			changes[at] = canonicalNameEdits(references)
+		}
+	}
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+// requested returns whether code actions of the given kind are among the ones the client asked for with only.
+// Clients that do not restrict kinds are not sent source actions.
+func requested(only []protocol.CodeActionKind, kind protocol.CodeActionKind) bool {
+	for _, asked := range only {
+		if kind == asked || strings.HasPrefix(string(kind), string(asked)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isInRepository returns whether an entry of repo is referred to by name, the same way unknown tags and technologies are diagnosed.
+func isInRepository(kind string, name string, repo []yaml.Node) bool {
+	var err error
+	if kind == "tag" {
+		_, _, err = FindInRepository[ortfodb.Tag](name, kind, repo)
+	} else {
+		_, _, err = FindInRepository[ortfodb.Technology](name, kind, repo)
+	}
+	return err == nil
+}
+
+func closestInRepository(kind string, name string, repo []yaml.Node) (string, bool) {
+	if kind == "tag" {
+		return ClosestInRepository[ortfodb.Tag](name, repo)
+	}
+	return ClosestInRepository[ortfodb.Technology](name, repo)
+}
+
+// skeletonEntry returns the fields of a new repository entry referred to by name, with only its required fields set.
+func skeletonEntry(kind string, name string) [][2]string {
+	if kind == "tag" {
+		plural := name
+		if !strings.HasSuffix(name, "s") {
+			plural += "s"
+		}
+		return [][2]string{{"singular", name}, {"plural", plural}}
+	}
+	return [][2]string{{"slug", slugify(name)}, {"name", name}}
+}
+
+// slugify lowercases s and replaces runs of characters that are not letters or digits with dashes.
+func slugify(s string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return slug.String()
+}
+
+// appendRepositoryEntry returns an edit that adds an entry with the given fields at the end of a repository file.
+// The entry is indented like the first entry of the file, and the rest of the file is left untouched.
+func appendRepositoryEntry(contents string, fields [][2]string) (protocol.TextEdit, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(contents), &document); err != nil {
+		return protocol.TextEdit{}, fmt.Errorf("while parsing repository: %w", err)
+	}
+
+	lines := strings.Split(contents, "\n")
+	prefix := "- "
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		if root.Kind != yaml.SequenceNode || root.Style&yaml.FlowStyle != 0 {
+			return protocol.TextEdit{}, fmt.Errorf("repository is not a block-style sequence")
+		}
+		if len(root.Content) > 0 {
+			first := root.Content[0]
+			if line := lines[first.Line-1]; first.Column-1 <= len(line) && strings.HasSuffix(strings.TrimRight(line[:first.Column-1], " "), "-") {
+				prefix = line[:first.Column-1]
+			}
+		}
+	}
+
+	entry := make([]string, 0, len(fields))
+	for i, field := range fields {
+		indentation := strings.Repeat(" ", len(prefix))
+		if i == 0 {
+			indentation = prefix
+		}
+		entry = append(entry, indentation+field[0]+": "+yamlScalar(field[1]))
+	}
+
+	last := uint32(len(lines) - 1)
+	if strings.TrimSuffix(lines[last], "\r") == "" {
+		return insertion(protocol.Position{Line: last}, strings.Join(entry, "\n")+"\n"), nil
+	}
+	return insertion(endOfLine(lines, last), "\n"+strings.Join(entry, "\n")), nil
+}
+
+// diagnosticsAt returns the diagnostics with the given code that span exactly at.
+func diagnosticsAt(diagnostics []protocol.Diagnostic, code string, at protocol.Range) []protocol.Diagnostic {
+	matching := make([]protocol.Diagnostic, 0)
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == code && diagnostic.Range == at {
+			matching = append(matching, diagnostic)
+		}
+	}
+	return matching
+}
+
+// rangesOverlap returns whether a and b have at least one position in common.
+func rangesOverlap(a protocol.Range, b protocol.Range) bool {
+	return !isAfter(a.Start, b.End) && !isAfter(b.Start, a.End)
+}
@@ -0,0 +1,143 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+func TestAppendRepositoryEntry(t *testing.T) {
+	for _, test := range []struct {
+		contents string
+		at       protocol.Position
+		expected string
+	}{
+		{
+			contents: "# tags\n- singular: website\n  plural: websites\n",
+			at:       protocol.Position{Line: 3},
+			expected: "- singular: web design\n  plural: web designs\n",
+		},
+		{
+			contents: "  -   singular: website\n      plural: websites # comment",
+			at:       protocol.Position{Line: 1, Character: 32},
+			expected: "\n  -   singular: web design\n      plural: web designs",
+		},
+		{
+			contents: "",
+			at:       protocol.Position{},
+			expected: "- singular: web design\n  plural: web designs\n",
+		},
+	} {
+		edit, err := appendRepositoryEntry(test.contents, skeletonEntry("tag", "web design"))
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.contents, err)
+			continue
+		}
+		if edit.Range.Start != test.at || edit.Range.End != test.at || edit.NewText != test.expected {
+			t.Errorf("expected %q inserted at %v, got %q at %v", test.expected, test.at, edit.NewText, edit.Range)
+		}
+	}
+
+	if _, err := appendRepositoryEntry("[]", skeletonEntry("tag", "web")); err == nil {
+		t.Errorf("expected an error for flow-style repositories")
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	for input, expected := range map[string]string{
+		"Go":                  "go",
+		"Adobe After Effects": "adobe-after-effects",
+		" C++ ":               "c",
+		"Node.js":             "node-js",
+	} {
+		if slug := slugify(input); slug != expected {
+			t.Errorf("slugify(%q): expected %q, got %q", input, expected, slug)
+		}
+	}
+}
+
+func TestNonCanonicalReferences(t *testing.T) {
+	technologies, err := ParseRepository([]byte("- slug: javascript\n  name: JavaScript\n  aliases: [js]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := ParseDescriptionFile("---\nmade with: [js, javascript, JavaScript, unknown]\n---\n", protocol.Position{})
+	for setting, expected := range map[string][]string{
+		canonicalURLFriendlyName: {"js", "JavaScript"},
+		canonicalDisplayName:     {"js", "javascript"},
+		canonicalOff:             {},
+	} {
+		references := file.NonCanonicalReferences(state{technologies: technologies}, setting)
+		if len(references) != len(expected) {
+			t.Errorf("%s: expected %d references, got %d: %+v", setting, len(expected), len(references), references)
+			continue
+		}
+		for i, name := range expected {
+			if references[i].node.Value != name {
+				t.Errorf("%s: expected %q, got %q", setting, name, references[i].node.Value)
+			}
+		}
+	}
+}
+
+func TestUnknownReferrableFixesWithUnreadableRepository(t *testing.T) {
+	root := t.TempDir()
+	configPath := writePortfolio(t, root, filepath.Join(root, "tags.yaml"))
+	technologiesRepository := filepath.Join(root, "technologies.yaml")
+	if err := os.WriteFile(technologiesRepository, []byte("- slug: javascript\n  name: JavaScript\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(technologiesRepository); err != nil {
+		t.Fatal(err)
+	}
+
+	h := Handler{Workspace: workspace, Logger: zap.NewNop()}
+	documentURI := uri.File(filepath.Join(root, "projects", "app", "description.md"))
+	file := ParseDescriptionFile("---\nmade with: [javascrip]\n---\n", protocol.Position{})
+	actions, err := h.unknownReferrableFixes(documentURI, file, span(1, 13, 1, 13), []protocol.Diagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Title != `Replace with "javascript"` {
+		t.Errorf("expected only the replacement with the closest technology, got %+v", actions)
+	}
+}
+
+func TestUnknownReferrableFixesWhenDegraded(t *testing.T) {
+	root := t.TempDir()
+	tagsRepository := filepath.Join(root, "tags.yaml")
+	configPath := writePortfolio(t, root, tagsRepository)
+	if err := os.WriteFile(tagsRepository, []byte("- singular: site\n  plural: sites\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the previous state is kept, but the tags repository the configuration points to might have changed
+	if err := os.WriteFile(configPath, []byte("tags: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.Reload(configPath); err == nil {
+		t.Fatal("expected the broken configuration to prevent reloading")
+	}
+
+	h := Handler{Workspace: workspace, Logger: zap.NewNop()}
+	file := ParseDescriptionFile("---\ntags: [web]\n---\n", protocol.Position{})
+	actions, err := h.unknownReferrableFixes(uri.File(filepath.Join(root, "projects", "app", "description.md")), file, span(1, 8, 1, 8), []protocol.Diagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("expected no fixes while the portfolio is degraded, got %+v", actions)
+	}
+}
@@ -1,12 +1,14 @@
 package languageserver
 
 import (
+	"fmt"
 	"math"
 	"regexp"
 
 	"github.com/mazznoer/csscolorparser"
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
 func decodeColorLiteral(raw string) protocol.Color {
@@ -41,6 +43,37 @@ func encodeColorLiteral(color protocol.Color) string {
 	return out
 }
 
+// ColorDiagnostics reports values of the colors frontmatter key that are not valid colors.
+func (d DescriptionFile) ColorDiagnostics() []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	colors, ok := d.frontmatterMappings["colors"]
+	if !ok || colors.Kind != yaml.MappingNode {
+		return diagnostics
+	}
+
+	for i := 0; i+1 < len(colors.Content); i += 2 {
+		value := colors.Content[i+1]
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			continue
+		}
+
+		raw := value.Value
+		if hexLike(raw) {
+			raw = "#" + raw
+		}
+		if _, err := csscolorparser.Parse(raw); err != nil {
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range:    nodeRange(value),
+				Severity: protocol.DiagnosticSeverityError,
+				Code:     "invalid-color",
+				Source:   diagnosticsSource,
+				Message:  fmt.Sprintf("%q is not a valid color", value.Value),
+			})
+		}
+	}
+	return diagnostics
+}
+
 func hexLike(s string) bool {
 	return regexp.MustCompile(`^[0-9a-fA-F]{3,8}$`).MatchString(s)
 }
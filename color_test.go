@@ -68,3 +68,11 @@ func randomColor() protocol.Color {
 		Alpha: roundToThree(rand.Float64()),
 	}
 }
+
+func TestColorDiagnostics(t *testing.T) {
+	file := ParseDescriptionFile("---\ncolors:\n  primary: ff0000\n  secondary: rebeccapurple\n  tertiary: notacolor\n---\n", protocol.Position{})
+	diagnostics := file.ColorDiagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Range.Start.Line != 4 || diagnostics[0].Code != "invalid-color" {
+		t.Errorf("expected an invalid-color diagnostic on line 4, got %+v", diagnostics)
+	}
+}
@@ -0,0 +1,118 @@
+package languageserver
+
+import (
+	"regexp"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// sequenceItemStart matches the start of a sequence item, in block style (- item) or flow style ([item, item]), up to the cursor.
+var sequenceItemStart = regexp.MustCompile(`(^\s*-\s*|\[\s*|,\s*)([^,\[\]]*)$`)
+
+// SequenceItemUnderCursor returns what was already typed of the sequence item under the cursor, along with the range that a completion should replace.
+// ok is false if the cursor is not on a sequence item.
+func (d DescriptionFile) SequenceItemUnderCursor() (typed string, replace protocol.Range, ok bool) {
+	if int(d.cursor.Line) >= len(d.lines) {
+		return "", protocol.Range{}, false
+	}
+
+	line := d.CurrentLine()
+	cursor := utf16ColumnToByte(line, d.cursor.Character)
+	match := sequenceItemStart.FindStringSubmatch(line[:cursor])
+	if match == nil {
+		return "", protocol.Range{}, false
+	}
+
+	typed = match[2]
+	start := cursor - len(typed)
+	end := cursor
+	if rest := strings.IndexAny(line[cursor:], ",]"); rest != -1 {
+		end = cursor + len(strings.TrimRight(line[cursor:cursor+rest], " "))
+	} else {
+		end = len(strings.TrimRight(line, " "))
+	}
+
+	return typed, lineRange(d.cursor.Line, line, start, max(end, cursor)), true
+}
+
+// SequenceValues returns the scalar values of the sequence at the given frontmatter key.
+func (d DescriptionFile) SequenceValues(key string) []string {
+	node, ok := d.frontmatterMappings[key]
+	if !ok || node.Kind != yaml.SequenceNode {
+		return []string{}
+	}
+
+	values := make([]string, 0, len(node.Content))
+	for _, child := range node.Content {
+		if child.Kind == yaml.ScalarNode {
+			values = append(values, child.Value)
+		}
+	}
+	return values
+}
+
+// RepositoryCompletionItems returns completion items for every entry of repo that can be referred to by a name starting with typed.
+// Entries that are already referred to by one of alreadyUsed are left out.
+// repository is the path of the file repo was loaded from, so that items can be resolved against the same portfolio.
+func RepositoryCompletionItems[T referrable](kind string, repo []yaml.Node, repository string, typed string, replace protocol.Range, alreadyUsed []string) []protocol.CompletionItem {
+	items := make([]protocol.CompletionItem, 0, len(repo))
+	for _, node := range repo {
+		var item T
+		if err := node.Decode(&item); err != nil {
+			logger.Debug("RepositoryCompletionItems:could not decode", zap.Any("node", node), zap.Error(err))
+			continue
+		}
+
+		if referredToByAny(item, alreadyUsed, typed) {
+			continue
+		}
+
+		matched, ok := nameStartingWith(item, typed)
+		if !ok {
+			continue
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:      item.DisplayName(),
+			Kind:       protocol.CompletionItemKindEnumMember,
+			Detail:     kind + " " + item.URLFriendlyName(),
+			FilterText: matched,
+			TextEdit: &protocol.TextEdit{
+				Range:   replace,
+				NewText: item.URLFriendlyName(),
+			},
+			Data: map[string]string{
+				"kind":       kind,
+				"name":       item.URLFriendlyName(),
+				"repository": repository,
+			},
+		})
+	}
+	return items
+}
+
+// nameStartingWith returns the first name item can be referred to by that starts with prefix, ignoring case.
+func nameStartingWith(item referrable, prefix string) (string, bool) {
+	if prefix == "" {
+		return item.DisplayName(), true
+	}
+
+	for _, name := range namesOf(item) {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func referredToByAny(item referrable, names []string, except string) bool {
+	for _, name := range names {
+		if name != except && item.ReferredToBy(name) {
+			return true
+		}
+	}
+	return false
+}
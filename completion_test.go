@@ -0,0 +1,51 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestSequenceItemUnderCursor(t *testing.T) {
+	cases := []struct {
+		line      string
+		character uint32
+		typed     string
+		start     uint32
+		end       uint32
+		ok        bool
+	}{
+		{"  - web", 7, "web", 4, 7, true},
+		{"  - ", 4, "", 4, 4, true},
+		{"tags: [web, desi]", 16, "desi", 12, 16, true},
+		{"tags: [web, desi]", 13, "d", 12, 16, true},
+		{"tags: []", 7, "", 7, 7, true},
+		{"tags: web", 9, "", 0, 0, false},
+		{"title: Hello", 12, "", 0, 0, false},
+		// columns are in UTF-16 code units: é is one unit but two bytes, 🎨 is two units and four bytes
+		{"  - développement", 6, "dé", 4, 17, true},
+		{"tags: [café, dé]", 15, "dé", 13, 15, true},
+		{"tags: [🎨 art, x]", 9, "🎨", 7, 13, true},
+	}
+
+	for _, c := range cases {
+		file := DescriptionFile{
+			lines:  []string{c.line},
+			cursor: protocol.Position{Line: 0, Character: c.character},
+		}
+		typed, replace, ok := file.SequenceItemUnderCursor()
+		if ok != c.ok {
+			t.Errorf("%q at %d: expected ok=%v, got %v", c.line, c.character, c.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if typed != c.typed {
+			t.Errorf("%q at %d: expected typed %q, got %q", c.line, c.character, c.typed, typed)
+		}
+		if replace.Start.Character != c.start || replace.End.Character != c.end {
+			t.Errorf("%q at %d: expected range %d-%d, got %d-%d", c.line, c.character, c.start, c.end, replace.Start.Character, replace.End.Character)
+		}
+	}
+}
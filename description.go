@@ -2,7 +2,6 @@ package languageserver
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"go.lsp.dev/protocol"
@@ -10,8 +9,6 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var descriptionFiles = make(map[protocol.URI]string, 0)
-
 type DescriptionFile struct {
 	contents            string
 	lines               []string
@@ -19,100 +16,89 @@ type DescriptionFile struct {
 	frontmatter         *yaml.Node
 	frontmatterEndsAt   protocol.Position
 	frontmatterMappings map[string]yaml.Node
+	// frontmatterErr is set when the frontmatter could not be parsed. The frontmatter is then considered empty.
+	frontmatterErr error
 }
 
 func (d DescriptionFile) CurrentLine() string {
 	return d.lines[d.cursor.Line]
 }
 
+// InFrontmatter returns the top-level frontmatter key the cursor is under, along with the scalar value under the cursor.
+// closestNode is nil when the cursor is inside an empty sequence.
+// found is false if the cursor is not on a scalar value (or inside an empty sequence) of the frontmatter.
 func (d DescriptionFile) InFrontmatter() (closestKey string, closestNode *yaml.Node, found bool) {
-	logger.Debug("InFrontmatter with", zap.Any("d", d))
-	if isAfter(d.cursor, d.frontmatterEndsAt) {
-		return "", nil, false
-	}
-
-	if len(d.frontmatter.Content) == 0 {
+	path, node, found := d.NodeAtCursor()
+	logger.Debug("InFrontmatter", zap.Stringer("path", path), zap.Any("node", node), zap.Bool("found", found))
+	if !found || len(path) == 0 {
 		return "", nil, false
 	}
 
-	key := ""
-	for i, node := range d.frontmatter.Content {
-		if i%2 == 0 {
-			logger.Debug("InFrontmatter:key", zap.Any("node", node), zap.Int("i", i))
-			key = node.Value
-			continue
-		}
-
-		var nextNode *yaml.Node
-		if len(d.frontmatter.Content) <= i+1 {
-			nextNode = nil
-		} else {
-			nextNode = d.frontmatter.Content[i+1]
-		}
-
-		logger.Debug("InFrontmatter:value", zap.Any("node", node), zap.Int("i", i), zap.Any("nextNode", nextNode))
-		if nextNode == nil || isAfter(positionOf(nextNode), d.cursor) {
-			switch node.Kind {
-			case yaml.SequenceNode:
-				return key, closestNodeInArray(node, d.cursor), true
-			case yaml.ScalarNode:
-				return key, node, true
-			default:
-				return "", nil, false
-			}
-		}
+	switch {
+	case node.Kind == yaml.ScalarNode:
+		return path[0].key, node, true
+	case node.Kind == yaml.SequenceNode && len(node.Content) == 0:
+		return path[0].key, nil, true
 	}
 	return "", nil, false
 }
 
-func loadFile(uri protocol.URI) (string, error) {
-	logger.Debug("loading from disk", zap.Any("uri", uri))
-	contentsRaw, err := os.ReadFile(uri.Filename())
-	if err != nil {
-		return "", fmt.Errorf("while reading file at %s: %w", uri.Filename(), err)
+// NodeAtCursor returns the deepest frontmatter node under the cursor, and the path that leads to it from the root of the frontmatter.
+// found is false if the cursor is not inside the frontmatter.
+func (d DescriptionFile) NodeAtCursor() (path YAMLPath, node *yaml.Node, found bool) {
+	if isAfter(d.cursor, d.frontmatterEndsAt) || len(d.frontmatter.Content) == 0 {
+		return YAMLPath{}, nil, false
 	}
-	contents := string(contentsRaw)
-	descriptionFiles[uri] = contents
-	return contents, nil
-}
 
-func CurrentFile(uri protocol.URI, cursor protocol.Position) (DescriptionFile, error) {
-	var contents string
-	if contentsFromMap, ok := descriptionFiles[uri]; ok {
-		contents = contentsFromMap
-	} else {
-		var err error
-		contents, err = loadFile(uri)
-		if err != nil {
-			return DescriptionFile{}, fmt.Errorf("while loading file from disk: %w", err)
-		}
-	}
+	path, node = nodeAt(d.frontmatter, d.cursor, YAMLPath{})
+	return path, node, true
+}
 
-	var frontmatter yaml.Node
+// ParseDescriptionFile parses the contents of a description file, with the cursor at the given position.
+func ParseDescriptionFile(contents string, cursor protocol.Position) DescriptionFile {
 	frontmatterRaw, frontmatterBoundaryLineNumber := extractFrontmatter(contents)
-	if err := yaml.Unmarshal([]byte(frontmatterRaw), &frontmatter); err != nil {
-		return DescriptionFile{}, fmt.Errorf("while parsing frontmatter: %w", err)
-	}
-
-	frontmatter = *frontmatter.Content[0]
-	var frontmatterMappings map[string]yaml.Node
-
-	err := yaml.Unmarshal([]byte(frontmatterRaw), &frontmatterMappings)
-	if err != nil {
-		return DescriptionFile{}, fmt.Errorf("frontmatter is not a mapping: %w", err)
+	frontmatter, frontmatterMappings, frontmatterErr := parseFrontmatter(frontmatterRaw)
+	if frontmatterErr != nil {
+		logger.Debug("could not parse frontmatter", zap.Error(frontmatterErr))
 	}
 
 	return DescriptionFile{
 		contents:            contents,
 		lines:               strings.Split(contents, "\n"),
 		cursor:              cursor,
-		frontmatter:         &frontmatter,
+		frontmatter:         frontmatter,
 		frontmatterMappings: frontmatterMappings,
+		frontmatterErr:      frontmatterErr,
 		frontmatterEndsAt: protocol.Position{
 			Line:      uint32(frontmatterBoundaryLineNumber),
 			Character: 0,
 		},
-	}, nil
+	}
+}
+
+// parseFrontmatter parses the raw frontmatter as a YAML mapping.
+// If it is not valid, an empty mapping is returned along with the error, so that features that do not need the frontmatter keep working.
+func parseFrontmatter(raw string) (*yaml.Node, map[string]yaml.Node, error) {
+	empty := &yaml.Node{Kind: yaml.MappingNode}
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &document); err != nil {
+		return empty, map[string]yaml.Node{}, fmt.Errorf("while parsing frontmatter: %w", err)
+	}
+
+	if len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+		return empty, map[string]yaml.Node{}, nil
+	}
+
+	if document.Content[0].Kind != yaml.MappingNode {
+		return empty, map[string]yaml.Node{}, fmt.Errorf("frontmatter is not a mapping")
+	}
+
+	var mappings map[string]yaml.Node
+	if err := document.Content[0].Decode(&mappings); err != nil {
+		return empty, map[string]yaml.Node{}, fmt.Errorf("frontmatter is not a mapping: %w", err)
+	}
+
+	return document.Content[0], mappings, nil
 }
 
 func extractFrontmatter(contents string) (string, int) {
@@ -0,0 +1,59 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+const testDescription = `---
+tags: [website, design]
+made with:
+  - go
+  - svelte
+colors:
+  primary: "#ff0000"
+  secondary: blue
+layout:
+  - m1
+  - [p1, m2]
+  - [p2, l1, m3]
+---
+
+# Project
+`
+
+func TestNodeAtCursor(t *testing.T) {
+	cases := []struct {
+		line      uint32
+		character uint32
+		path      string
+		value     string
+	}{
+		{1, 17, "tags[1]", "design"},
+		{1, 8, "tags[0]", "website"},
+		{4, 5, "made with[1]", "svelte"},
+		{6, 14, "colors.primary", "#ff0000"},
+		{7, 13, "colors.secondary", "blue"},
+		{9, 4, "layout[0]", "m1"},
+		{11, 9, "layout[2][1]", "l1"},
+		{11, 15, "layout[2][2]", "m3"},
+	}
+
+	for _, c := range cases {
+		file := ParseDescriptionFile(testDescription, protocol.Position{Line: c.line, Character: c.character})
+		path, node, found := file.NodeAtCursor()
+		if !found {
+			t.Errorf("%d:%d: expected to be in frontmatter", c.line, c.character)
+			continue
+		}
+		if path.String() != c.path || node.Value != c.value {
+			t.Errorf("%d:%d: expected %s = %q, got %s = %q", c.line, c.character, c.path, c.value, path, node.Value)
+		}
+	}
+
+	file := ParseDescriptionFile(testDescription, protocol.Position{Line: 14, Character: 2})
+	if _, _, found := file.NodeAtCursor(); found {
+		t.Errorf("cursor after the frontmatter should not be in it")
+	}
+}
@@ -0,0 +1,95 @@
+package languageserver
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const diagnosticsSource = "ortfols"
+
+// PublishDiagnostics computes diagnostics for the description or repository file at uri and sends them to the client.
+// Other files only get diagnostics for the errors that prevented portfolios from being loaded because of them.
+func (h Handler) PublishDiagnostics(ctx context.Context, uri protocol.URI) error {
+	var diagnostics []protocol.Diagnostic
+	if repository, ok, err := h.repositoryFile(uri, protocol.Position{}); ok {
+		if err != nil {
+			return h.makeErr("while getting repository file", err)
+		}
+		diagnostics = repository.Diagnostics()
+	} else if filepath.Base(uri.Filename()) == "description.md" {
+		file, err := h.Workspace.CurrentFile(uri, protocol.Position{})
+		if err != nil {
+			return h.makeErr("while getting current file", err)
+		}
+		diagnostics = file.Diagnostics(h.stateOf(uri), h.Workspace.Settings(), filepath.Dir(uri.Filename()))
+	} else {
+		diagnostics = h.loadErrorDiagnostics(uri)
+	}
+
+	h.Logger.Debug("PublishDiagnostics", zap.Any("uri", uri), zap.Any("diagnostics", diagnostics))
+	return h.Client.PublishDiagnostics(ctx, &protocol.PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// Diagnostics returns all problems found in the description file, workFolder being the folder it is in.
+func (d DescriptionFile) Diagnostics(s state, settings Settings, workFolder string) []protocol.Diagnostic {
+	diagnostics := append(d.SchemaDiagnostics(), d.MediaDiagnostics(workFolder)...)
+	diagnostics = append(diagnostics, d.ColorDiagnostics()...)
+	diagnostics = append(diagnostics, d.LayoutDiagnostics()...)
+	if s.degraded {
+		// the repositories might be missing or outdated
+		return diagnostics
+	}
+
+	diagnostics = append(diagnostics, d.CanonicalNameDiagnostics(s, settings.canonicalName())...)
+	if tags, ok := d.frontmatterMappings["tags"]; ok {
+		diagnostics = append(diagnostics, unknownReferrablesDiagnostics[ortfodb.Tag]("tag", &tags, s.tags)...)
+	}
+	if technologies, ok := d.frontmatterMappings["made with"]; ok {
+		diagnostics = append(diagnostics, unknownReferrablesDiagnostics[ortfodb.Technology]("technology", &technologies, s.technologies)...)
+	}
+	return diagnostics
+}
+
+// unknownReferrablesDiagnostics reports every item of sequence that no entry of repo is referred to by.
+func unknownReferrablesDiagnostics[T referrable](kind string, sequence *yaml.Node, repo []yaml.Node) []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	if sequence.Kind != yaml.SequenceNode {
+		return diagnostics
+	}
+
+	for _, item := range sequence.Content {
+		if item.Kind != yaml.ScalarNode || item.Value == "" {
+			continue
+		}
+
+		if _, _, err := FindInRepository[T](item.Value, kind, repo); err == nil {
+			continue
+		}
+
+		message := fmt.Sprintf("%s %q not found in repository", kind, item.Value)
+		if suggestion, ok := ClosestInRepository[T](item.Value, repo); ok {
+			message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Range: protocol.Range{
+				Start: positionOf(item),
+				End:   endPositionOf(item),
+			},
+			Severity: protocol.DiagnosticSeverityWarning,
+			Code:     "unknown-" + kind,
+			Source:   diagnosticsSource,
+			Message:  message,
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,95 @@
+package languageserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+const testTechnologiesRepository = `- slug: javascript
+  name: JavaScript
+  aliases: [js]
+- slug: go
+  name: Go
+`
+
+func TestUnknownReferrablesDiagnostics(t *testing.T) {
+	technologies, err := ParseRepository([]byte(testTechnologiesRepository))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{
+		"javascript": "",
+		"JavaScript": "",
+		"js":         "",
+		"rust":       `technology "rust" not found in repository`,
+		"javascrip":  `technology "javascrip" not found in repository (did you mean "javascript"?)`,
+	} {
+		sequence := ParseDescriptionFile("---\nmade with: ["+name+"]\n---\n", protocol.Position{}).frontmatterMappings["made with"]
+		diagnostics := unknownReferrablesDiagnostics[ortfodb.Technology]("technology", &sequence, technologies)
+		if expected == "" {
+			if len(diagnostics) != 0 {
+				t.Errorf("%s: expected no diagnostics, got %v", name, diagnostics)
+			}
+			continue
+		}
+		at := span(1, 12, 1, 12+uint32(len(name)))
+		if len(diagnostics) != 1 || diagnostics[0].Message != expected || diagnostics[0].Range != at || diagnostics[0].Code != "unknown-technology" {
+			t.Errorf("%s: expected %q on %v, got %v", name, expected, at, diagnostics)
+		}
+	}
+}
+
+func TestClosestInRepository(t *testing.T) {
+	technologies, err := ParseRepository([]byte(testTechnologiesRepository))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{
+		"javascrpt":  "javascript",
+		"Javascript": "javascript",
+		"JS":         "js",
+		"jsx":        "js",
+		"golang":     "",
+		"rust":       "",
+	} {
+		closest, ok := ClosestInRepository[ortfodb.Technology](name, technologies)
+		if closest != expected || ok != (expected != "") {
+			t.Errorf("%s: expected %q, got %q", name, expected, closest)
+		}
+	}
+}
+
+func TestUnknownReferenceDefinitionAndHover(t *testing.T) {
+	root := t.TempDir()
+	configPath := writePortfolio(t, root, filepath.Join(root, "tags.yaml"))
+	descriptionFile := uri.File(filepath.Join(root, "projects", "app", "description.md"))
+	if err := os.MkdirAll(filepath.Dir(descriptionFile.Filename()), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(descriptionFile.Filename(), []byte("---\ntags: [typo]\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Handler{Workspace: workspace, Logger: zap.NewNop()}
+	at := protocol.TextDocumentPositionParams{TextDocument: protocol.TextDocumentIdentifier{URI: descriptionFile}, Position: protocol.Position{Line: 1, Character: 8}}
+
+	if locations, err := h.Definition(context.Background(), &protocol.DefinitionParams{TextDocumentPositionParams: at}); err != nil || len(locations) != 0 {
+		t.Errorf("expected no definition for an unknown tag, got %v (%v)", locations, err)
+	}
+	if hover, err := h.Hover(context.Background(), &protocol.HoverParams{TextDocumentPositionParams: at}); err != nil || hover != nil {
+		t.Errorf("expected no hover for an unknown tag, got %v (%v)", hover, err)
+	}
+}
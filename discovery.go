@@ -0,0 +1,148 @@
+package languageserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// ConfigurationFilename is the name of the ortfodb configuration files looked for in workspace folders and in the parent directories of opened files.
+const ConfigurationFilename = "ortfodb.yaml"
+
+// configurationAbove returns the path of the closest ortfodb configuration file in directory or one of its parents.
+func configurationAbove(directory string) (string, bool) {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		candidate := filepath.Join(directory, ConfigurationFilename)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return "", false
+		}
+		directory = parent
+	}
+}
+
+// workspaceFolders returns the paths of the workspace folders the client was started with, or of its root folder for clients that do not support workspace folders.
+func workspaceFolders(params *protocol.InitializeParams) []string {
+	folders := make([]string, 0, len(params.WorkspaceFolders))
+	for _, folder := range params.WorkspaceFolders {
+		folders = append(folders, uri.URI(folder.URI).Filename())
+	}
+
+	if len(folders) == 0 {
+		switch {
+		case params.RootURI != "":
+			folders = append(folders, params.RootURI.Filename())
+		case params.RootPath != "":
+			folders = append(folders, params.RootPath)
+		}
+	}
+	return folders
+}
+
+// addWorkspaceFolders loads the portfolio of every folder that is part of one.
+// The returned error combines the errors that prevented portfolios from being loaded, which are then in a degraded state.
+func (h Handler) addWorkspaceFolders(folders []string) error {
+	var errs error
+	for _, folder := range folders {
+		configPath, ok := configurationAbove(folder)
+		if !ok {
+			h.Logger.Debug("no ortfodb configuration found for workspace folder", zap.String("folder", folder))
+			continue
+		}
+
+		h.Workspace.SetFolderConfiguration(folder, configPath)
+		if h.Workspace.Loaded(configPath) {
+			continue
+		}
+		if err := h.Workspace.Load(configPath); err != nil {
+			h.Logger.Warn("could not load ortfodb configuration of workspace folder", zap.String("folder", folder), zap.String("configpath", configPath), zap.Error(err))
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		h.Logger.Info("Loaded ortfodb configuration", zap.String("folder", folder), zap.String("configpath", configPath))
+	}
+	return errs
+}
+
+// removeWorkspaceFolders forgets the portfolios of the folders, unless other workspace folders are part of them.
+// Portfolios of open documents are loaded again afterwards by the caller.
+func (h Handler) removeWorkspaceFolders(folders []string) {
+	for _, folder := range folders {
+		if configPath, unused := h.Workspace.RemoveFolder(folder); unused {
+			h.Logger.Info("Unloading ortfodb configuration", zap.String("folder", folder), zap.String("configpath", configPath))
+			h.Workspace.Unload(configPath)
+		}
+	}
+}
+
+// loadFallbackConfiguration loads the ortfodb configuration given on the command line if no portfolio is loaded.
+// If it does not exist, the workspace stays empty until a file of a portfolio is opened.
+func (h Handler) loadFallbackConfiguration() error {
+	if len(h.Workspace.ConfigPaths()) > 0 {
+		return nil
+	}
+
+	if _, err := os.Stat(h.FallbackConfigurationPath); err != nil {
+		h.Logger.Warn("no ortfodb configuration found, waiting for a file of a portfolio to be opened", zap.String("configpath", h.FallbackConfigurationPath), zap.Error(err))
+		return nil
+	}
+
+	if err := h.Workspace.Load(h.FallbackConfigurationPath); err != nil {
+		h.Logger.Warn("could not load fallback ortfodb configuration", zap.String("configpath", h.FallbackConfigurationPath), zap.Error(err))
+		return err
+	}
+	h.Logger.Info("Loaded fallback ortfodb configuration", zap.String("configpath", h.FallbackConfigurationPath))
+	return nil
+}
+
+// discoverConfiguration loads the ortfodb configuration found above the file at documentURI, if it is not loaded yet.
+// Diagnostics of the other open documents are published again, since some of them might belong to the new portfolio.
+func (h Handler) discoverConfiguration(ctx context.Context, documentURI protocol.URI) error {
+	loaded, err := h.loadConfigurationAbove(documentURI)
+	if !loaded {
+		return nil
+	}
+	return multierr.Append(h.reportLoadErrors(ctx, err), h.republishDiagnostics(ctx, documentURI))
+}
+
+// loadConfigurationAbove loads the ortfodb configuration found above the file at documentURI, and returns whether it was not loaded yet.
+// The returned error is what prevented the portfolio from being loaded, it is then in a degraded state.
+func (h Handler) loadConfigurationAbove(documentURI protocol.URI) (bool, error) {
+	configPath, ok := configurationAbove(filepath.Dir(documentURI.Filename()))
+	if !ok || h.Workspace.Loaded(configPath) {
+		return false, nil
+	}
+
+	h.Logger.Info("Found ortfodb configuration above opened file", zap.String("configpath", configPath), zap.String("file", documentURI.Filename()))
+	if err := h.Workspace.Load(configPath); err != nil {
+		h.Logger.Warn("could not load ortfodb configuration", zap.String("configpath", configPath), zap.Error(err))
+		return true, err
+	}
+	return true, nil
+}
+
+// republishDiagnostics publishes diagnostics again for every open document except the ones in except.
+func (h Handler) republishDiagnostics(ctx context.Context, except ...protocol.URI) error {
+	var errs error
+	for _, uri := range h.Workspace.OpenDocuments() {
+		if !slices.Contains(except, uri) {
+			errs = multierr.Append(errs, h.PublishDiagnostics(ctx, uri))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,29 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigurationAbove(t *testing.T) {
+	portfolio := t.TempDir()
+	project := filepath.Join(portfolio, "projects", "alpha", ".ortfo")
+	if err := os.MkdirAll(project, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(portfolio, ConfigurationFilename)
+	if err := os.WriteFile(configPath, []byte("projects at: projects\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, directory := range []string{portfolio, project} {
+		if found, ok := configurationAbove(directory); !ok || found != configPath {
+			t.Errorf("from %s: expected %s, got %q", directory, configPath, found)
+		}
+	}
+
+	if found, ok := configurationAbove(filepath.Dir(portfolio)); ok {
+		t.Errorf("expected no configuration above %s, got %s", filepath.Dir(portfolio), found)
+	}
+}
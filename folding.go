@@ -0,0 +1,59 @@
+package languageserver
+
+import (
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// FoldingRanges returns the folding ranges of the description file: the frontmatter, multi-line layout and colors, language sections, and runs of footnote and abbreviation definitions.
+func (d DescriptionFile) FoldingRanges() []protocol.FoldingRange {
+	ranges := make([]protocol.FoldingRange, 0)
+	fold := func(start, end int) {
+		if end > start {
+			ranges = append(ranges, protocol.FoldingRange{
+				StartLine: uint32(start),
+				EndLine:   uint32(end),
+				Kind:      protocol.RegionFoldingRange,
+			})
+		}
+	}
+
+	if d.frontmatterEndsAt.Line > 0 {
+		fold(0, int(d.frontmatterEndsAt.Line))
+	}
+
+	for i := 0; i+1 < len(d.frontmatter.Content); i += 2 {
+		key, value := d.frontmatter.Content[i], d.frontmatter.Content[i+1]
+		if key.Value != "layout" && key.Value != "colors" {
+			continue
+		}
+		if value.Kind == yaml.SequenceNode || value.Kind == yaml.MappingNode {
+			fold(int(positionOf(key).Line), int(lastLineOf(value)))
+		}
+	}
+
+	for _, section := range d.LanguageSections() {
+		if section.Marker != -1 {
+			fold(section.Marker, section.End)
+		}
+
+		runStart := -1
+		for i, block := range section.Blocks {
+			isDefinition := block.Kind == blockFootnote || block.Kind == blockAbbreviation
+			if isDefinition && runStart == -1 {
+				runStart = i
+			}
+
+			last := i == len(section.Blocks)-1
+			if runStart != -1 && (!isDefinition || last) {
+				runEnd := i - 1
+				if isDefinition {
+					runEnd = i
+				}
+				fold(section.Blocks[runStart].Start, section.Blocks[runEnd].End)
+				runStart = -1
+			}
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,327 @@
+package languageserver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaKey describes a key that ortfodb understands, in the frontmatter of description.md files or in entries of the repository files.
+type schemaKey struct {
+	Name string
+	// Kind is the kind of YAML node expected as the value. A null value is always accepted.
+	Kind          yaml.Kind
+	Documentation string
+	// Required is true if the key must be present.
+	Required bool
+	// Children describes the keys of the value, when Kind is yaml.MappingNode.
+	Children []schemaKey
+}
+
+// frontmatterSchema lists every frontmatter key ortfodb understands. See ortfodb.WorkMetadata.
+var frontmatterSchema = []schemaKey{
+	{
+		Name:          "wip",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Whether the work is still in progress (`true` or `false`).",
+	},
+	{
+		Name:          "private",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Whether the work should be hidden from public listings (`true` or `false`).",
+	},
+	{
+		Name:          "started",
+		Kind:          yaml.ScalarNode,
+		Documentation: "When work on this project started, as an ISO 8601 date. Unknown parts can be replaced with `?`, e.g. `2021-??-??`.",
+	},
+	{
+		Name:          "finished",
+		Kind:          yaml.ScalarNode,
+		Documentation: "When work on this project finished, as an ISO 8601 date. Unknown parts can be replaced with `?`. Leave empty if the work is still in progress.",
+	},
+	{
+		Name:          "created",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Overrides the date used to sort works chronologically. Defaults to `finished`, or `started` if the work is not finished.",
+	},
+	{
+		Name:          "tags",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Tags of the work. Each tag must be defined in the tags repository, and can be referred to by its singular name, plural name or any of its aliases.",
+	},
+	{
+		Name:          "made with",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Technologies used to make the work. Each technology must be defined in the technologies repository, and can be referred to by its slug, name or any of its aliases.",
+	},
+	{
+		Name:          "aliases",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Other IDs this work can be referred to by.",
+	},
+	{
+		Name:          "layout",
+		Kind:          yaml.SequenceNode,
+		Documentation: "How content blocks are laid out. Each row is either a single block reference or a list of block references that share the row. A block reference is `p`, `m` or `l` (paragraph, media or link) followed by the 1-based index of the block of that type, e.g. `m2`.",
+	},
+	{
+		Name:          "thumbnail",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Path to the media used as the work's thumbnail, relative to the description file. Defaults to the first media.",
+	},
+	{
+		Name:          "title style",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Style of the title, free for the website to interpret.",
+	},
+	{
+		Name:          "page background",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Background of the work's page, free for the website to interpret.",
+	},
+	{
+		Name:          "colors",
+		Kind:          yaml.MappingNode,
+		Documentation: "Color palette of the work. Extracted from the thumbnail when not specified and color extraction is enabled.",
+		Children: []schemaKey{
+			{Name: "primary", Kind: yaml.ScalarNode, Documentation: "Primary color of the work."},
+			{Name: "secondary", Kind: yaml.ScalarNode, Documentation: "Secondary color of the work."},
+			{Name: "tertiary", Kind: yaml.ScalarNode, Documentation: "Tertiary color of the work."},
+		},
+	},
+}
+
+// frontmatterKeyLine matches a line that only contains a (possibly partially-typed) mapping key, up to the cursor.
+var frontmatterKeyLine = regexp.MustCompile(`^(\s*)([\w ]*)$`)
+
+func findSchemaKey(keys []schemaKey, name string) (schemaKey, bool) {
+	for _, key := range keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return schemaKey{}, false
+}
+
+// Description returns the documentation of this key, as shown on hover.
+func (k schemaKey) Description() protocol.MarkupContent {
+	return protocol.MarkupContent{
+		Kind:  protocol.Markdown,
+		Value: fmt.Sprintf("# `%s` (%s)\n\n%s", k.Name, kindName(k.Kind), k.Documentation),
+	}
+}
+
+// ValueDescription returns the documentation of this key, as shown when hovering the value at path.
+func (k schemaKey) ValueDescription(path YAMLPath) protocol.MarkupContent {
+	return protocol.MarkupContent{
+		Kind:  protocol.Markdown,
+		Value: fmt.Sprintf("# `%s`\n\n%s", path, k.Documentation),
+	}
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.AliasNode:
+		return "alias"
+	}
+	return "document"
+}
+
+// KeysAtCursor returns the path of frontmatter keys leading to the mapping key the cursor is on, e.g. ["colors", "primary"].
+// found is false if the cursor is not on a mapping key.
+func (d DescriptionFile) KeysAtCursor() (path []string, found bool) {
+	return keysAt(d.frontmatter, d.cursor, []string{})
+}
+
+func keysAt(mapping *yaml.Node, cursor protocol.Position, parents []string) ([]string, bool) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		path := append(append([]string{}, parents...), key.Value)
+		if positionOf(key).Line == cursor.Line && !isAfter(positionOf(key), cursor) && !isAfter(cursor, endPositionOf(key)) {
+			return path, true
+		}
+
+		if found, ok := keysAt(value, cursor, path); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// schemaOf returns the schema of the frontmatter key at the given path.
+func schemaOf(path []string) (schemaKey, bool) {
+	return schemaKeyAt(frontmatterSchema, path)
+}
+
+// schemaKeyAt returns the schema of the key at the given path, starting from the keys of schema.
+func schemaKeyAt(schema []schemaKey, path []string) (schemaKey, bool) {
+	keys := schema
+	var key schemaKey
+	for _, name := range path {
+		var ok bool
+		key, ok = findSchemaKey(keys, name)
+		if !ok {
+			return schemaKey{}, false
+		}
+		keys = key.Children
+	}
+	return key, len(path) > 0
+}
+
+// KeyCompletionItems returns completion items for frontmatter keys, if the cursor is where a key is expected.
+func (d DescriptionFile) KeyCompletionItems() []protocol.CompletionItem {
+	items := make([]protocol.CompletionItem, 0)
+	if d.cursor.Line == 0 || !isAfter(d.frontmatterEndsAt, d.cursor) || int(d.cursor.Line) >= len(d.lines) {
+		return items
+	}
+
+	line := d.CurrentLine()
+	cursor := utf16ColumnToByte(line, d.cursor.Character)
+	match := frontmatterKeyLine.FindStringSubmatch(line[:cursor])
+	if match == nil {
+		return items
+	}
+
+	indentation, typed := match[1], match[2]
+	keys := frontmatterSchema
+	present := d.frontmatterMappings
+	if indentation != "" {
+		parent, ok := d.parentKeyOf(int(d.cursor.Line))
+		if !ok {
+			return items
+		}
+
+		schema, ok := findSchemaKey(frontmatterSchema, parent)
+		if !ok || schema.Kind != yaml.MappingNode {
+			return items
+		}
+
+		keys = schema.Children
+		present = map[string]yaml.Node{}
+		if value, ok := d.frontmatterMappings[parent]; ok {
+			value.Decode(&present)
+		}
+	}
+
+	end := len(line)
+	if colon := strings.Index(line, ":"); colon != -1 {
+		end = colon + 1
+	}
+
+	for _, key := range keys {
+		if _, ok := present[key.Name]; ok && key.Name != typed {
+			continue
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:         key.Name,
+			Kind:          protocol.CompletionItemKindProperty,
+			Detail:        kindName(key.Kind),
+			Documentation: key.Description(),
+			TextEdit: &protocol.TextEdit{
+				Range:   lineRange(d.cursor.Line, line, len(indentation), end),
+				NewText: key.Name + ":",
+			},
+		})
+	}
+	return items
+}
+
+// parentKeyOf returns the top-level frontmatter key under which the given line is nested.
+func (d DescriptionFile) parentKeyOf(line int) (string, bool) {
+	for i := line - 1; i > 0; i-- {
+		if strings.TrimSpace(d.lines[i]) == "" || strings.HasPrefix(d.lines[i], " ") || strings.HasPrefix(d.lines[i], "\t") {
+			continue
+		}
+		key, _, ok := strings.Cut(d.lines[i], ":")
+		return strings.TrimSpace(key), ok
+	}
+	return "", false
+}
+
+// SchemaDiagnostics reports frontmatter syntax errors, unknown keys and values of the wrong kind.
+func (d DescriptionFile) SchemaDiagnostics() []protocol.Diagnostic {
+	if d.frontmatterErr != nil {
+		return []protocol.Diagnostic{yamlErrorDiagnostic(d.frontmatterErr, d.lines, "invalid-frontmatter")}
+	}
+
+	return schemaDiagnostics(d.frontmatter, frontmatterSchema, "", "unknown frontmatter key %q, it will be kept as additional metadata")
+}
+
+// schemaDiagnostics reports unknown keys and values of the wrong kind in mapping.
+// unknownKeyMessage is formatted with the full name of unknown keys.
+func schemaDiagnostics(mapping *yaml.Node, schema []schemaKey, parent string, unknownKeyMessage string) []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keyNode, value := mapping.Content[i], mapping.Content[i+1]
+		keyRange := protocol.Range{Start: positionOf(keyNode), End: endPositionOf(keyNode)}
+		key, ok := findSchemaKey(schema, keyNode.Value)
+		if !ok {
+			message := fmt.Sprintf(unknownKeyMessage, parent+keyNode.Value)
+			if suggestion, ok := closestSchemaKey(schema, keyNode.Value); ok {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range:    keyRange,
+				Severity: protocol.DiagnosticSeverityWarning,
+				Code:     "unknown-key",
+				Source:   diagnosticsSource,
+				Message:  message,
+			})
+			continue
+		}
+
+		if value.Tag == "!!null" {
+			continue
+		}
+
+		if value.Kind != key.Kind {
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range:    keyRange,
+				Severity: protocol.DiagnosticSeverityError,
+				Code:     "wrong-kind",
+				Source:   diagnosticsSource,
+				Message:  fmt.Sprintf("%s should be a %s, not a %s", parent+key.Name, kindName(key.Kind), kindName(value.Kind)),
+			})
+			continue
+		}
+
+		if key.Kind == yaml.MappingNode {
+			diagnostics = append(diagnostics, schemaDiagnostics(value, key.Children, parent+key.Name+".", unknownKeyMessage)...)
+		}
+	}
+	return diagnostics
+}
+
+func closestSchemaKey(schema []schemaKey, name string) (string, bool) {
+	closest := ""
+	bestDistance := max(1, len(name)/3) + 1
+	for _, key := range schema {
+		if distance := editDistance(strings.ToLower(name), key.Name); distance < bestDistance {
+			bestDistance = distance
+			closest = key.Name
+		}
+	}
+	return closest, closest != ""
+}
+
+func (d DescriptionFile) lineAt(line int) string {
+	if line < 0 || line >= len(d.lines) {
+		return ""
+	}
+	return d.lines[line]
+}
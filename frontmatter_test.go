@@ -0,0 +1,104 @@
+package languageserver
+
+import (
+	"slices"
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestSchemaDiagnostics(t *testing.T) {
+	for _, test := range []struct {
+		frontmatter string
+		code        string
+		message     string
+		at          protocol.Range
+	}{
+		{
+			frontmatter: "wip: true\nstarted: 2024-01-01\n",
+		},
+		{
+			frontmatter: "startd: 2024-01-01\n",
+			code:        "unknown-key",
+			message:     `unknown frontmatter key "startd", it will be kept as additional metadata (did you mean "started"?)`,
+			at:          span(1, 0, 1, 6),
+		},
+		{
+			frontmatter: "tags: web\n",
+			code:        "wrong-kind",
+			message:     "tags should be a sequence, not a scalar",
+			at:          span(1, 0, 1, 4),
+		},
+		{
+			frontmatter: "colors:\n  primary: red\n  quaternary: blue\n",
+			code:        "unknown-key",
+			message:     `unknown frontmatter key "colors.quaternary", it will be kept as additional metadata`,
+			at:          span(3, 2, 3, 12),
+		},
+		{
+			frontmatter: "colors:\n  primary: [red]\n",
+			code:        "wrong-kind",
+			message:     "colors.primary should be a scalar, not a sequence",
+			at:          span(2, 2, 2, 9),
+		},
+		{
+			frontmatter: "tags: [\n",
+			code:        "invalid-frontmatter",
+		},
+	} {
+		diagnostics := ParseDescriptionFile("---\n"+test.frontmatter+"---\n", protocol.Position{}).SchemaDiagnostics()
+		if test.code == "" {
+			if len(diagnostics) != 0 {
+				t.Errorf("%q: expected no diagnostics, got %v", test.frontmatter, diagnostics)
+			}
+			continue
+		}
+		if len(diagnostics) != 1 || diagnostics[0].Code != test.code {
+			t.Errorf("%q: expected a %s diagnostic, got %v", test.frontmatter, test.code, diagnostics)
+			continue
+		}
+		if test.message != "" && (diagnostics[0].Message != test.message || diagnostics[0].Range != test.at) {
+			t.Errorf("%q: expected %q at %v, got %q at %v", test.frontmatter, test.message, test.at, diagnostics[0].Message, diagnostics[0].Range)
+		}
+	}
+}
+
+func TestKeyCompletionItems(t *testing.T) {
+	for _, test := range []struct {
+		contents string
+		cursor   protocol.Position
+		expected []string
+		replace  protocol.Range
+	}{
+		// keys already present are only known while the frontmatter is valid YAML
+		{
+			contents: "---\nwip: true\nta:\n---\n",
+			cursor:   protocol.Position{Line: 2, Character: 2},
+			expected: []string{"private", "started", "finished", "created", "tags", "made with", "aliases", "layout", "thumbnail", "title style", "page background", "colors"},
+			replace:  span(2, 0, 2, 3),
+		},
+		{
+			contents: "---\ncolors:\n  primary: \"#fff\"\n  se:\n---\n",
+			cursor:   protocol.Position{Line: 3, Character: 4},
+			expected: []string{"secondary", "tertiary"},
+			replace:  span(3, 2, 3, 5),
+		},
+		{
+			contents: "---\ntitle style: café\n---\n",
+			cursor:   protocol.Position{Line: 1, Character: 17},
+			expected: []string{},
+		},
+	} {
+		items := ParseDescriptionFile(test.contents, test.cursor).KeyCompletionItems()
+		labels := make([]string, 0, len(items))
+		for _, item := range items {
+			labels = append(labels, item.Label)
+			if item.TextEdit.Range != test.replace {
+				t.Errorf("%q: expected %s to replace %v, got %v", test.contents, item.Label, test.replace, item.TextEdit.Range)
+			}
+		}
+		if !slices.Equal(labels, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.contents, test.expected, labels)
+		}
+	}
+}
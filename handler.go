@@ -4,76 +4,96 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	ortfodb "github.com/ortfo/db"
 	"go.lsp.dev/protocol"
 	"go.lsp.dev/uri"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
 
 var YAMLSeparator = regexp.MustCompile(ortfodb.PatternYAMLSeparator)
-var logger *zap.Logger
 
-type state struct {
-	config       ortfodb.Configuration
-	tags         []yaml.Node
-	technologies []yaml.Node
-}
+// logger is used by functions that have no handler to log with. It is set once when the server starts.
+var logger = zap.NewNop()
 
 type Handler struct {
 	protocol.Server
-	Logger *zap.Logger
-}
-
-func (h Handler) state(ctx context.Context) state {
-	return ctx.Value("state").(state)
+	Client    protocol.Client
+	Logger    *zap.Logger
+	Workspace *Workspace
+	// FallbackConfigurationPath is the ortfodb configuration used when none is found in the workspace folders or above opened files.
+	FallbackConfigurationPath string
 }
 
-func (h Handler) config(ctx context.Context) ortfodb.Configuration {
-	return h.state(ctx).config
+// stateOf returns the state of the portfolio the file at uri belongs to.
+func (h Handler) stateOf(uri protocol.URI) state {
+	return h.Workspace.StateOf(uri)
 }
 
-func NewHandler(ctx context.Context, server protocol.Server, logger *zap.Logger) (Handler, context.Context, error) {
-	config, err := ortfodb.NewConfiguration(ctx.Value("configpath").(string))
-	if err != nil {
-		return Handler{}, ctx, fmt.Errorf("while loading ortfodb configuration from ./ortfodb.yaml: %w", err)
-	}
-
-	tags, err := LoadRepository(config.Tags.Repository)
-	if err != nil {
-		return Handler{}, ctx, fmt.Errorf("while loading tags from repository: %w", err)
-	}
-
-	technologies, err := LoadRepository(config.Technologies.Repository)
-	if err != nil {
-		return Handler{}, ctx, fmt.Errorf("while loading technologies from repository: %w", err)
-	}
-
+// NewHandler returns a handler with an empty workspace, whose ortfodb configuration is discovered when the client initializes the server.
+func NewHandler(fallbackConfigurationPath string, server protocol.Server, client protocol.Client, logger *zap.Logger) Handler {
 	return Handler{
-			Server: server,
-			Logger: logger,
-		}, context.WithValue(ctx, "state", state{
-			config:       config,
-			tags:         tags,
-			technologies: technologies,
-		}), nil
+		Server:                    server,
+		Client:                    client,
+		Logger:                    logger,
+		Workspace:                 newWorkspace(logger),
+		FallbackConfigurationPath: fallbackConfigurationPath,
+	}
 }
 
 func (h Handler) Initialize(ctx context.Context, params *protocol.InitializeParams) (*protocol.InitializeResult, error) {
-	logger = h.Logger
 	h.Logger.Debug("Initializing ortfols server")
+	settings, err := decodeSettings(params.InitializationOptions)
+	if err != nil {
+		h.Logger.Error("could not read initialization options", zap.Error(err))
+	}
+	h.Workspace.SetSettings(settings)
+	// errors are reported once initialized, the client cannot receive diagnostics before that
+	h.addWorkspaceFolders(workspaceFolders(params))
+	h.loadFallbackConfiguration()
 	return &protocol.InitializeResult{
 		Capabilities: protocol.ServerCapabilities{
 			DefinitionProvider: true,
 			HoverProvider:      true,
-			ColorProvider:      true,
+			ReferencesProvider: true,
+			RenameProvider: &protocol.RenameOptions{
+				PrepareProvider: true,
+			},
+			ColorProvider:           true,
+			DocumentSymbolProvider:  true,
+			FoldingRangeProvider:    true,
+			WorkspaceSymbolProvider: true,
+			SemanticTokensProvider: map[string]interface{}{
+				"legend": semanticTokensLegend,
+				"full":   map[string]bool{"delta": true},
+				"range":  true,
+			},
+			Workspace: &protocol.ServerCapabilitiesWorkspace{
+				WorkspaceFolders: &protocol.ServerCapabilitiesWorkspaceFolders{
+					Supported:           true,
+					ChangeNotifications: true,
+				},
+			},
+			DocumentLinkProvider: &protocol.DocumentLinkOptions{
+				ResolveProvider: true,
+			},
+			CodeActionProvider: &protocol.CodeActionOptions{
+				CodeActionKinds: []protocol.CodeActionKind{protocol.QuickFix, sourceFixAll},
+			},
+			CompletionProvider: &protocol.CompletionOptions{
+				ResolveProvider:   true,
+				TriggerCharacters: []string{"-", "[", ",", " ", "(", "/"},
+			},
 			TextDocumentSync: protocol.TextDocumentSyncOptions{
 				OpenClose: true,
-				Change:    protocol.TextDocumentSyncKindFull,
+				Change:    protocol.TextDocumentSyncKindIncremental,
 				Save: &protocol.SaveOptions{
 					IncludeText: false,
 				},
@@ -81,52 +101,61 @@ func (h Handler) Initialize(ctx context.Context, params *protocol.InitializePara
 		},
 		ServerInfo: &protocol.ServerInfo{
 			Name:    "ortfols",
-			Version: fmt.Sprintf("%s (built at %s)", ortfodb.Version, BuiltAt),
+			Version: Version(),
 		},
 	}, nil
 }
 
 func (h Handler) Definition(ctx context.Context, params *protocol.DefinitionParams) ([]protocol.Location, error) {
-	h.Logger.Debug("LSP:Definition", zap.Any("state", h.state), zap.Any("params", params))
-	file, err := CurrentFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
+	current := h.stateOf(params.TextDocumentPositionParams.TextDocument.URI)
+	h.Logger.Debug("LSP:Definition", zap.Any("state", current), zap.Any("params", params))
+	file, err := h.Workspace.CurrentFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
 	if err != nil {
 		return []protocol.Location{}, fmt.Errorf("while getting current file: %w", err)
 	}
 
-	if key, node, inside := file.InFrontmatter(); inside {
+	if key, node, inside := file.InFrontmatter(); inside && node != nil {
 		h.Logger.Debug("Found frontmatter key", zap.String("key", key), zap.Any("node", node))
 		switch key {
 		case "tags":
-			node, _, err := FindInRepository[ortfodb.Tag](node.Value, "tag", h.state(ctx).tags)
-			pos := positionOf(node)
-			return []protocol.Location{
-				{
-					URI: uri.File(h.config(ctx).Tags.Repository),
-					Range: protocol.Range{
-						Start: pos,
-						End:   pos,
-					},
-				},
-			}, err
+			entry, _, err := FindInRepository[ortfodb.Tag](node.Value, "tag", current.tags)
+			return definitionIn(current.config.Tags.Repository, entry, err)
 		case "made with":
-			node, _, err := FindInRepository[ortfodb.Technology](node.Value, "technology", h.state(ctx).technologies)
-			pos := positionOf(node)
-			return []protocol.Location{
-				{
-					URI: uri.File(h.config(ctx).Technologies.Repository),
-					Range: protocol.Range{
-						Start: pos,
-						End:   pos,
-					},
-				},
-			}, err
+			entry, _, err := FindInRepository[ortfodb.Technology](node.Value, "technology", current.technologies)
+			return definitionIn(current.config.Technologies.Repository, entry, err)
 		}
 	}
 	return []protocol.Location{}, nil
 }
 
+// definitionIn returns the location of entry in the repository file, as found by FindInRepository.
+// Unknown tags and technologies have no definition: they are reported by diagnostics already.
+func definitionIn(repository string, entry *yaml.Node, err error) ([]protocol.Location, error) {
+	if errors.Is(err, errNotInRepository) {
+		return []protocol.Location{}, nil
+	}
+	if err != nil {
+		return []protocol.Location{}, err
+	}
+
+	pos := positionOf(entry)
+	return []protocol.Location{
+		{
+			URI: uri.File(repository),
+			Range: protocol.Range{
+				Start: pos,
+				End:   pos,
+			},
+		},
+	}, nil
+}
+
 func (h Handler) Initialized(ctx context.Context, params *protocol.InitializedParams) error {
-	return nil
+	var errs error
+	for _, loadErr := range h.Workspace.loadErrors() {
+		errs = multierr.Append(errs, loadErr)
+	}
+	return h.reportLoadErrors(ctx, errs)
 }
 
 func (h Handler) Shutdown(ctx context.Context) error {
@@ -150,7 +179,26 @@ func (h Handler) SetTrace(ctx context.Context, params *protocol.SetTraceParams)
 }
 
 func (h Handler) CodeAction(ctx context.Context, params *protocol.CodeActionParams) ([]protocol.CodeAction, error) {
-	return nil, errors.New("unimplemented")
+	h.Logger.Debug("LSP:CodeAction", zap.Any("params", params))
+	if filepath.Base(params.TextDocument.URI.Filename()) != "description.md" {
+		return []protocol.CodeAction{}, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(params.TextDocument.URI, params.Range.Start)
+	if err != nil {
+		return []protocol.CodeAction{}, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	actions, err := h.unknownReferrableFixes(params.TextDocument.URI, file, params.Range, params.Context.Diagnostics)
+	if err != nil {
+		return actions, h.makeErr("while computing quick fixes", err)
+	}
+
+	canonicalizations, err := h.canonicalNameFixes(params.TextDocument.URI, file, params.Range, params.Context)
+	if err != nil {
+		return actions, h.makeErr("while computing canonical names fixes", err)
+	}
+	return append(actions, canonicalizations...), nil
 }
 
 func (h Handler) CodeLens(ctx context.Context, params *protocol.CodeLensParams) ([]protocol.CodeLens, error) {
@@ -162,7 +210,7 @@ func (h Handler) CodeLensResolve(ctx context.Context, params *protocol.CodeLens)
 }
 
 func (h Handler) ColorPresentation(ctx context.Context, params *protocol.ColorPresentationParams) ([]protocol.ColorPresentation, error) {
-	logger.Debug("LSP:ColorPresentation", zap.Any("color", params.Color))
+	h.Logger.Debug("LSP:ColorPresentation", zap.Any("color", params.Color))
 	return []protocol.ColorPresentation{
 		{
 			Label: encodeColorLiteral(params.Color),
@@ -175,11 +223,78 @@ func (h Handler) ColorPresentation(ctx context.Context, params *protocol.ColorPr
 }
 
 func (h Handler) Completion(ctx context.Context, params *protocol.CompletionParams) (*protocol.CompletionList, error) {
-	return nil, errors.New("unimplemented")
+	h.Logger.Debug("LSP:Completion", zap.Any("params", params))
+	repository, ok, err := h.repositoryFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &protocol.CompletionList{Items: repository.FieldCompletionItems()}, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
+	if err != nil {
+		return nil, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	if items, ok := file.MediaCompletionItems(filepath.Dir(params.TextDocumentPositionParams.TextDocument.URI.Filename())); ok {
+		return &protocol.CompletionList{Items: items}, nil
+	}
+
+	if items := file.KeyCompletionItems(); len(items) > 0 {
+		return &protocol.CompletionList{Items: items}, nil
+	}
+
+	key, _, inside := file.InFrontmatter()
+	if !inside {
+		return &protocol.CompletionList{}, nil
+	}
+
+	typed, replace, onItem := file.SequenceItemUnderCursor()
+	if !onItem {
+		return &protocol.CompletionList{}, nil
+	}
+
+	h.Logger.Debug("Completing sequence item", zap.String("key", key), zap.String("typed", typed))
+	current := h.stateOf(params.TextDocumentPositionParams.TextDocument.URI)
+	switch key {
+	case "tags":
+		return &protocol.CompletionList{
+			Items: RepositoryCompletionItems[ortfodb.Tag]("tag", current.tags, current.config.Tags.Repository, typed, replace, file.SequenceValues(key)),
+		}, nil
+	case "made with":
+		return &protocol.CompletionList{
+			Items: RepositoryCompletionItems[ortfodb.Technology]("technology", current.technologies, current.config.Technologies.Repository, typed, replace, file.SequenceValues(key)),
+		}, nil
+	}
+	return &protocol.CompletionList{}, nil
 }
 
 func (h Handler) CompletionResolve(ctx context.Context, params *protocol.CompletionItem) (*protocol.CompletionItem, error) {
-	return nil, errors.New("unimplemented")
+	data, ok := params.Data.(map[string]interface{})
+	if !ok {
+		return params, nil
+	}
+
+	name, _ := data["name"].(string)
+	repository, _ := data["repository"].(string)
+	// the repository file belongs to the portfolio of the description file the item was completed in
+	current := h.stateOf(uri.File(repository))
+	switch data["kind"] {
+	case "tag":
+		_, tag, err := FindInRepository[ortfodb.Tag](name, "tag", current.tags)
+		if err != nil {
+			return params, err
+		}
+		params.Documentation = ReferrableDescription(tag, tag.Description)
+	case "technology":
+		_, technology, err := FindInRepository[ortfodb.Technology](name, "technology", current.technologies)
+		if err != nil {
+			return params, err
+		}
+		params.Documentation = ReferrableDescription(technology, technology.Description)
+	}
+	return params, nil
 }
 
 func (h Handler) Declaration(ctx context.Context, params *protocol.DeclarationParams) ([]protocol.Location, error) {
@@ -187,54 +302,101 @@ func (h Handler) Declaration(ctx context.Context, params *protocol.DeclarationPa
 }
 
 func (h Handler) DidChange(ctx context.Context, params *protocol.DidChangeTextDocumentParams) error {
-	if filepath.Base(params.TextDocument.URI.Filename()) != "description.md" {
-		return nil
-	}
-
 	if len(params.ContentChanges) == 0 {
 		return nil
 	}
 
-	lastChange := params.ContentChanges[len(params.ContentChanges)-1]
-
-	logger.Debug("DidChange", zap.String("lastChange.Text", lastChange.Text))
-	descriptionFiles[params.TextDocument.URI] = lastChange.Text
-	return nil
+	h.Logger.Debug("DidChange", zap.Any("changes", params.ContentChanges))
+	if err := h.Workspace.Edit(params.TextDocument.URI, params.TextDocument.Version, params.ContentChanges); err != nil {
+		return h.makeErr("while applying changes", err)
+	}
+	h.Workspace.Reindex(params.TextDocument.URI.Filename())
+	return h.PublishDiagnostics(ctx, params.TextDocument.URI)
 }
 
 func (h Handler) DidChangeConfiguration(ctx context.Context, params *protocol.DidChangeConfigurationParams) error {
-	return errors.New("unimplemented")
+	h.Logger.Debug("LSP:DidChangeConfiguration", zap.Any("params", params))
+	sections, ok := params.Settings.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	settings, err := decodeSettings(sections["ortfo"])
+	if err != nil {
+		return h.makeErr("while reading settings", err)
+	}
+	h.Workspace.SetSettings(settings)
+	return h.republishDiagnostics(ctx)
 }
 
 func (h Handler) DidChangeWatchedFiles(ctx context.Context, params *protocol.DidChangeWatchedFilesParams) error {
-	return errors.New("unimplemented")
+	h.Logger.Debug("LSP:DidChangeWatchedFiles", zap.Any("params", params))
+	toReload := make([]string, 0)
+	for _, change := range params.Changes {
+		for _, configPath := range h.Workspace.portfoliosLoadedFrom(change.URI) {
+			if !slices.Contains(toReload, configPath) {
+				toReload = append(toReload, configPath)
+			}
+		}
+	}
+	if len(toReload) > 0 {
+		return h.Reload(ctx, toReload...)
+	}
+
+	for _, change := range params.Changes {
+		h.Workspace.Reindex(change.URI.Filename())
+	}
+	return nil
 }
 
 func (h Handler) DidChangeWorkspaceFolders(ctx context.Context, params *protocol.DidChangeWorkspaceFoldersParams) error {
-	return errors.New("unimplemented")
+	h.Logger.Debug("LSP:DidChangeWorkspaceFolders", zap.Any("params", params))
+	folders := func(changed []protocol.WorkspaceFolder) []string {
+		return workspaceFolders(&protocol.InitializeParams{WorkspaceFolders: changed})
+	}
+
+	h.removeWorkspaceFolders(folders(params.Event.Removed))
+	loadErrs := h.addWorkspaceFolders(folders(params.Event.Added))
+
+	// open documents keep the portfolio they belong to, even if it was the one of a removed folder
+	for _, uri := range h.Workspace.OpenDocuments() {
+		_, err := h.loadConfigurationAbove(uri)
+		loadErrs = multierr.Append(loadErrs, err)
+	}
+	loadErrs = multierr.Append(loadErrs, h.loadFallbackConfiguration())
+	return multierr.Append(h.reportLoadErrors(ctx, loadErrs), h.republishDiagnostics(ctx))
 }
 
 func (h Handler) DidClose(ctx context.Context, params *protocol.DidCloseTextDocumentParams) error {
-	logger.Debug("DidClose", zap.Any("descriptionFiles keys (before)", keys(descriptionFiles)))
-	delete(descriptionFiles, params.TextDocument.URI)
-	logger.Debug("DidClose", zap.Any("descriptionFiles keys (after)", keys(descriptionFiles)))
-	return nil
+	h.Workspace.Close(params.TextDocument.URI)
+	h.Workspace.Reindex(params.TextDocument.URI.Filename())
+	h.Logger.Debug("DidClose", zap.Any("open documents", h.Workspace.OpenDocuments()))
+	return h.Client.PublishDiagnostics(ctx, &protocol.PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []protocol.Diagnostic{},
+	})
 }
 
 func (h Handler) DidOpen(ctx context.Context, params *protocol.DidOpenTextDocumentParams) error {
-	logger.Debug("DidClose", zap.Any("descriptionFiles keys (before)", keys(descriptionFiles)))
-	loadFile(params.TextDocument.URI)
-	logger.Debug("DidClose", zap.Any("descriptionFiles keys (after)", keys(descriptionFiles)))
-	return errors.New("unimplemented")
+	h.Workspace.Open(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+	h.Workspace.Reindex(params.TextDocument.URI.Filename())
+	h.Logger.Debug("DidOpen", zap.Any("open documents", h.Workspace.OpenDocuments()))
+	if err := h.discoverConfiguration(ctx, params.TextDocument.URI); err != nil {
+		return err
+	}
+	return h.PublishDiagnostics(ctx, params.TextDocument.URI)
 }
 
 func (h Handler) DidSave(ctx context.Context, params *protocol.DidSaveTextDocumentParams) error {
-	loadFile(params.TextDocument.URI)
-	return nil
+	// recover as soon as the file that prevented portfolios from being loaded is fixed, even for clients that do not watch files
+	if failed := h.Workspace.failedPortfoliosLoadedFrom(params.TextDocument.URI); len(failed) > 0 {
+		return h.Reload(ctx, failed...)
+	}
+	return h.PublishDiagnostics(ctx, params.TextDocument.URI)
 }
 
 func (h Handler) DocumentColor(ctx context.Context, params *protocol.DocumentColorParams) ([]protocol.ColorInformation, error) {
-	file, err := CurrentFile(params.TextDocument.URI, protocol.Position{})
+	file, err := h.Workspace.CurrentFile(params.TextDocument.URI, protocol.Position{})
 	if err != nil {
 		return []protocol.ColorInformation{}, fmt.Errorf("while getting current file: %w", err)
 	}
@@ -263,7 +425,7 @@ func (h Handler) DocumentColor(ctx context.Context, params *protocol.DocumentCol
 		}
 	}
 
-	logger.Debug("DocumentColor", zap.Any("colors", colors))
+	h.Logger.Debug("DocumentColor", zap.Any("colors", colors))
 
 	return colors, nil
 }
@@ -273,15 +435,38 @@ func (h Handler) DocumentHighlight(ctx context.Context, params *protocol.Documen
 }
 
 func (h Handler) DocumentLink(ctx context.Context, params *protocol.DocumentLinkParams) ([]protocol.DocumentLink, error) {
-	return nil, errors.New("unimplemented")
+	if filepath.Base(params.TextDocument.URI.Filename()) != "description.md" {
+		return []protocol.DocumentLink{}, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(params.TextDocument.URI, protocol.Position{})
+	if err != nil {
+		return []protocol.DocumentLink{}, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	return file.DocumentLinks(h.stateOf(params.TextDocument.URI), filepath.Dir(params.TextDocument.URI.Filename())), nil
 }
 
 func (h Handler) DocumentLinkResolve(ctx context.Context, params *protocol.DocumentLink) (*protocol.DocumentLink, error) {
-	return nil, errors.New("unimplemented")
+	link := resolveDocumentLink(*params)
+	return &link, nil
 }
 
 func (h Handler) DocumentSymbol(ctx context.Context, params *protocol.DocumentSymbolParams) ([]interface{}, error) {
-	return nil, errors.New("unimplemented")
+	if filepath.Base(params.TextDocument.URI.Filename()) != "description.md" {
+		return []interface{}{}, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(params.TextDocument.URI, protocol.Position{})
+	if err != nil {
+		return []interface{}{}, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	symbols := make([]interface{}, 0)
+	for _, symbol := range file.DocumentSymbols() {
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
 }
 
 func (h Handler) ExecuteCommand(ctx context.Context, params *protocol.ExecuteCommandParams) (interface{}, error) {
@@ -289,7 +474,16 @@ func (h Handler) ExecuteCommand(ctx context.Context, params *protocol.ExecuteCom
 }
 
 func (h Handler) FoldingRanges(ctx context.Context, params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
-	return nil, errors.New("unimplemented")
+	if filepath.Base(params.TextDocument.URI.Filename()) != "description.md" {
+		return []protocol.FoldingRange{}, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(params.TextDocument.URI, protocol.Position{})
+	if err != nil {
+		return []protocol.FoldingRange{}, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	return file.FoldingRanges(), nil
 }
 
 func (h Handler) Formatting(ctx context.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
@@ -297,17 +491,71 @@ func (h Handler) Formatting(ctx context.Context, params *protocol.DocumentFormat
 }
 
 func (h Handler) Hover(ctx context.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
-	h.Logger.Debug("LSP:Hover", zap.Any("state", h.state(ctx)), zap.Any("params", params))
-	file, err := CurrentFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
+	current := h.stateOf(params.TextDocumentPositionParams.TextDocument.URI)
+	h.Logger.Debug("LSP:Hover", zap.Any("state", current), zap.Any("params", params))
+	repository, ok, err := h.repositoryFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return h.repositoryHover(repository, params.TextDocumentPositionParams.TextDocument.URI)
+	}
+
+	file, err := h.Workspace.CurrentFile(params.TextDocumentPositionParams.TextDocument.URI, params.TextDocumentPositionParams.Position)
 	if err != nil {
 		return nil, fmt.Errorf("while getting current file: %w", err)
 	}
 
-	if key, node, inside := file.InFrontmatter(); inside {
+	if embed, ok := file.MediaEmbedAtCursor(); ok && embed.Source != "" && !embed.IsRemote() {
+		path := embed.Path(filepath.Dir(params.TextDocumentPositionParams.TextDocument.URI.Filename()))
+		info, err := analyzeMedia(path)
+		if err != nil {
+			h.Logger.Debug("Hover:could not fully analyze media", zap.String("path", path), zap.Error(err))
+			if info.ContentType == "" {
+				return nil, nil
+			}
+		}
+		return &protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: info.Description(embed, path),
+			},
+			Range: &embed.Span,
+		}, nil
+	}
+
+	if path, onKey := file.KeysAtCursor(); onKey {
+		if key, ok := schemaOf(path); ok {
+			contents := key.Description()
+			if grid := file.LayoutGrid(); len(path) == 1 && path[0] == "layout" && grid != "" {
+				contents.Value += "\n\n" + grid
+			}
+			return &protocol.Hover{
+				Contents: contents,
+			}, nil
+		}
+		return nil, nil
+	}
+
+	if path, _, found := file.NodeAtCursor(); found && len(path) > 0 && path[0].key == "layout" {
+		if grid := file.LayoutGrid(); grid != "" {
+			return &protocol.Hover{
+				Contents: protocol.MarkupContent{
+					Kind:  protocol.Markdown,
+					Value: "# `layout`\n\n" + grid,
+				},
+			}, nil
+		}
+	}
+
+	if key, node, inside := file.InFrontmatter(); inside && node != nil {
 		h.Logger.Debug("Found frontmatter key", zap.String("key", key), zap.Any("node", node))
 		switch key {
 		case "tags":
-			_, tag, err := FindInRepository[ortfodb.Tag](node.Value, "tag", h.state(ctx).tags)
+			_, tag, err := FindInRepository[ortfodb.Tag](node.Value, "tag", current.tags)
+			if errors.Is(err, errNotInRepository) {
+				return nil, nil
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -315,7 +563,10 @@ func (h Handler) Hover(ctx context.Context, params *protocol.HoverParams) (*prot
 				Contents: ReferrableDescription(tag, tag.Description),
 			}, nil
 		case "made with":
-			_, technology, err := FindInRepository[ortfodb.Technology](node.Value, "technology", h.state(ctx).technologies)
+			_, technology, err := FindInRepository[ortfodb.Technology](node.Value, "technology", current.technologies)
+			if errors.Is(err, errNotInRepository) {
+				return nil, nil
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -325,6 +576,14 @@ func (h Handler) Hover(ctx context.Context, params *protocol.HoverParams) (*prot
 
 		}
 	}
+
+	if path, node, found := file.NodeAtCursor(); found && node.Kind == yaml.ScalarNode {
+		if key, ok := schemaOf(path.Keys()); ok {
+			return &protocol.Hover{
+				Contents: key.ValueDescription(path),
+			}, nil
+		}
+	}
 	return nil, nil
 }
 
@@ -337,7 +596,14 @@ func (h Handler) OnTypeFormatting(ctx context.Context, params *protocol.Document
 }
 
 func (h Handler) PrepareRename(ctx context.Context, params *protocol.PrepareRenameParams) (*protocol.Range, error) {
-	return nil, errors.New("unimplemented")
+	target, found, err := h.referenceTargetAt(params.TextDocument.URI, params.Position)
+	if err != nil {
+		return nil, h.makeErr("while finding what to rename", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &target.nameLocation.Range, nil
 }
 
 func (h Handler) RangeFormatting(ctx context.Context, params *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
@@ -345,11 +611,41 @@ func (h Handler) RangeFormatting(ctx context.Context, params *protocol.DocumentR
 }
 
 func (h Handler) References(ctx context.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
-	return nil, errors.New("unimplemented")
+	h.Logger.Debug("LSP:References", zap.Any("params", params))
+	target, found, err := h.referenceTargetAt(params.TextDocument.URI, params.Position)
+	if err != nil {
+		return []protocol.Location{}, h.makeErr("while finding what to search references of", err)
+	}
+	if !found {
+		return []protocol.Location{}, nil
+	}
+
+	locations, err := h.ReferencesTo(target)
+	if err != nil {
+		return []protocol.Location{}, h.makeErr("while searching references", err)
+	}
+
+	if params.Context.IncludeDeclaration {
+		locations = append([]protocol.Location{target.Declaration()}, locations...)
+	}
+	return locations, nil
 }
 
 func (h Handler) Rename(ctx context.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
-	return nil, errors.New("unimplemented")
+	h.Logger.Debug("LSP:Rename", zap.Any("params", params))
+	target, found, err := h.referenceTargetAt(params.TextDocument.URI, params.Position)
+	if err != nil {
+		return nil, h.makeErr("while finding what to rename", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("there is no tag or technology to rename here")
+	}
+
+	edit, err := h.RenameEdits(target, params.NewName, h.Workspace.Settings().Rename.KeepOldNameAsAlias)
+	if err != nil {
+		return nil, h.makeErr("while renaming", err)
+	}
+	return edit, nil
 }
 
 func (h Handler) SignatureHelp(ctx context.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
@@ -357,7 +653,12 @@ func (h Handler) SignatureHelp(ctx context.Context, params *protocol.SignatureHe
 }
 
 func (h Handler) Symbols(ctx context.Context, params *protocol.WorkspaceSymbolParams) ([]protocol.SymbolInformation, error) {
-	return nil, errors.New("unimplemented")
+	h.Logger.Debug("LSP:Symbols", zap.String("query", params.Query))
+	symbols, err := h.Workspace.SearchSymbols(params.Query)
+	if err != nil {
+		return []protocol.SymbolInformation{}, fmt.Errorf("while searching projects: %w", err)
+	}
+	return symbols, nil
 }
 
 func (h Handler) TypeDefinition(ctx context.Context, params *protocol.TypeDefinitionParams) ([]protocol.Location, error) {
@@ -417,15 +718,61 @@ func (h Handler) OutgoingCalls(ctx context.Context, params *protocol.CallHierarc
 }
 
 func (h Handler) SemanticTokensFull(ctx context.Context, params *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
-	return nil, errors.New("unimplemented")
+	tokens, err := h.semanticTokens(params.TextDocument.URI)
+	if err != nil {
+		return &protocol.SemanticTokens{Data: []uint32{}}, err
+	}
+
+	data := encodeSemanticTokens(tokens, 0, math.MaxUint32)
+	return &protocol.SemanticTokens{
+		ResultID: h.Workspace.RememberSemanticTokens(params.TextDocument.URI, data),
+		Data:     data,
+	}, nil
 }
 
 func (h Handler) SemanticTokensFullDelta(ctx context.Context, params *protocol.SemanticTokensDeltaParams) (interface{}, error) {
-	return nil, errors.New("unimplemented")
+	tokens, err := h.semanticTokens(params.TextDocument.URI)
+	if err != nil {
+		return &protocol.SemanticTokens{Data: []uint32{}}, err
+	}
+
+	data := encodeSemanticTokens(tokens, 0, math.MaxUint32)
+	previous, ok := h.Workspace.PreviousSemanticTokens(params.TextDocument.URI, params.PreviousResultID)
+	id := h.Workspace.RememberSemanticTokens(params.TextDocument.URI, data)
+	if !ok {
+		// the client's tokens are not the ones we last sent, send them all again
+		return &protocol.SemanticTokens{ResultID: id, Data: data}, nil
+	}
+
+	return &protocol.SemanticTokensDelta{
+		ResultID: id,
+		Edits:    semanticTokensEdits(previous, data),
+	}, nil
 }
 
 func (h Handler) SemanticTokensRange(ctx context.Context, params *protocol.SemanticTokensRangeParams) (*protocol.SemanticTokens, error) {
-	return nil, errors.New("unimplemented")
+	tokens, err := h.semanticTokens(params.TextDocument.URI)
+	if err != nil {
+		return &protocol.SemanticTokens{Data: []uint32{}}, err
+	}
+
+	return &protocol.SemanticTokens{
+		Data: encodeSemanticTokens(tokens, params.Range.Start.Line, params.Range.End.Line),
+	}, nil
+}
+
+// semanticTokens returns the semantic tokens of the document at uri, if it is a description file.
+func (h Handler) semanticTokens(uri protocol.URI) ([]semanticToken, error) {
+	if filepath.Base(uri.Filename()) != "description.md" {
+		return []semanticToken{}, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(uri, protocol.Position{})
+	if err != nil {
+		return []semanticToken{}, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	return file.SemanticTokens(h.stateOf(uri)), nil
 }
 
 func (h Handler) SemanticTokensRefresh(ctx context.Context) error {
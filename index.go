@@ -0,0 +1,342 @@
+package languageserver
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// maxSymbolResults is the maximum number of results returned by a workspace symbol search.
+const maxSymbolResults = 200
+
+// indexedSymbol is something a project can be found by.
+type indexedSymbol struct {
+	Name      string
+	Kind      protocol.SymbolKind
+	Container string
+	Range     protocol.Range
+}
+
+// indexedProject holds what a project can be found by.
+type indexedProject struct {
+	ID string
+	// Path is the path to the project's description file.
+	Path    string
+	Symbols []indexedSymbol
+}
+
+// ProjectIndex indexes the description files of every project of a portfolio, to search across them.
+// It is safe for concurrent use.
+type ProjectIndex struct {
+	mu sync.RWMutex
+	// projects maps paths of description files to their project. It is nil until the index is built.
+	projects map[string]indexedProject
+	// generation is incremented every time a description file changes, so that an index built from files that changed in the meantime is dropped.
+	generation uint64
+}
+
+// maxIndexBuilds is the number of times building an index is attempted while description files keep changing, before building it while holding its lock.
+const maxIndexBuilds = 3
+
+// projectIDOf returns the ID of the project whose description file is at path.
+// ok is false if path is not the description file of a project of the configured projects directory.
+func projectIDOf(config ortfodb.Configuration, path string) (id string, ok bool) {
+	if filepath.Base(path) != "description.md" {
+		return "", false
+	}
+
+	relative, err := filepath.Rel(config.ProjectsDirectory, path)
+	if err != nil {
+		return "", false
+	}
+
+	parts := strings.Split(filepath.ToSlash(relative), "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "..":
+		return parts[0], true
+	case len(parts) == 3 && parts[0] != ".." && parts[1] == config.ScatteredModeFolder:
+		return parts[0], true
+	}
+	return "", false
+}
+
+// indexProject returns what the project with the given ID and description file can be found by: its ID, its titles, tags, technologies and aliases.
+func indexProject(id string, path string, contents string) indexedProject {
+	file := ParseDescriptionFile(contents, protocol.Position{})
+	project := indexedProject{
+		ID:   id,
+		Path: path,
+		Symbols: []indexedSymbol{
+			{Name: id, Kind: protocol.SymbolKindModule, Range: file.linesRange(0, 0)},
+		},
+	}
+
+	for _, section := range file.LanguageSections() {
+		container := id
+		if section.Language != "" {
+			container = fmt.Sprintf("%s (%s)", id, section.Language)
+		}
+		for _, block := range section.Blocks {
+			if block.Kind == blockTitle {
+				project.Symbols = append(project.Symbols, indexedSymbol{
+					Name:      block.Name,
+					Kind:      protocol.SymbolKindClass,
+					Container: container,
+					Range:     file.linesRange(block.Start, block.Start),
+				})
+			}
+		}
+	}
+
+	for _, key := range []struct {
+		name string
+		kind protocol.SymbolKind
+	}{
+		{"tags", protocol.SymbolKindEnumMember},
+		{"made with", protocol.SymbolKindEnumMember},
+		{"aliases", protocol.SymbolKindConstant},
+	} {
+		sequence, ok := file.frontmatterMappings[key.name]
+		if !ok || sequence.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range sequence.Content {
+			if item.Kind != yaml.ScalarNode || item.Value == "" {
+				continue
+			}
+			project.Symbols = append(project.Symbols, indexedSymbol{
+				Name:      item.Value,
+				Kind:      key.kind,
+				Container: fmt.Sprintf("%s · %s", id, key.name),
+				Range:     protocol.Range{Start: positionOf(item), End: endPositionOf(item)},
+			})
+		}
+	}
+	return project
+}
+
+// Projects returns every indexed project of every portfolio, building their index first if needed.
+// Portfolios whose projects cannot be indexed are skipped, so that the others can still be searched.
+func (w *Workspace) Projects() ([]indexedProject, error) {
+	projects := make([]indexedProject, 0)
+	for _, loaded := range w.allPortfolios() {
+		indexed, err := w.projectsOf(loaded)
+		if err != nil {
+			w.logger.Warn("could not index projects, skipping portfolio", zap.String("configpath", loaded.configPath), zap.Error(err))
+			continue
+		}
+		projects = append(projects, indexed...)
+	}
+	return projects, nil
+}
+
+// projectsOf returns every indexed project of the portfolio, building its index first if needed.
+func (w *Workspace) projectsOf(p *portfolio) ([]indexedProject, error) {
+	p.index.mu.RLock()
+	built := p.index.projects != nil
+	p.index.mu.RUnlock()
+
+	if !built {
+		if err := w.buildIndex(p); err != nil {
+			return []indexedProject{}, err
+		}
+	}
+
+	p.index.mu.RLock()
+	defer p.index.mu.RUnlock()
+	projects := make([]indexedProject, 0, len(p.index.projects))
+	for _, project := range p.index.projects {
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// buildIndex builds the index of the portfolio from the current contents of its description files.
+// The files are read without holding the lock of the index; if one of them changes in the meantime, the result is dropped and the index built again.
+func (w *Workspace) buildIndex(p *portfolio) error {
+	for attempt := 1; attempt < maxIndexBuilds; attempt++ {
+		p.index.mu.RLock()
+		generation := p.index.generation
+		p.index.mu.RUnlock()
+
+		projects, err := w.indexProjects(p.state.config)
+		if err != nil {
+			return err
+		}
+
+		p.index.mu.Lock()
+		if p.index.projects == nil && p.index.generation == generation {
+			p.index.projects = projects
+		}
+		built := p.index.projects != nil
+		p.index.mu.Unlock()
+		if built {
+			return nil
+		}
+	}
+
+	// description files keep changing: build the index without letting them change
+	p.index.mu.Lock()
+	defer p.index.mu.Unlock()
+	if p.index.projects != nil {
+		return nil
+	}
+	projects, err := w.indexProjects(p.state.config)
+	if err != nil {
+		return err
+	}
+	p.index.projects = projects
+	return nil
+}
+
+// indexProjects returns the indexed projects of the portfolio configured by config, by path of their description file.
+func (w *Workspace) indexProjects(config ortfodb.Configuration) (map[string]indexedProject, error) {
+	paths, err := ProjectDescriptionFiles(config)
+	if err != nil {
+		return nil, fmt.Errorf("while listing description files: %w", err)
+	}
+
+	projects := make(map[string]indexedProject, len(paths))
+	for _, path := range paths {
+		id, ok := projectIDOf(config, path)
+		if !ok {
+			continue
+		}
+
+		contents, err := w.Contents(uri.File(path))
+		if err != nil {
+			w.logger.Debug("buildIndex:skipping unreadable description file", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		projects[path] = indexProject(id, path, contents)
+	}
+	return projects, nil
+}
+
+// Reindex updates the index of every portfolio with the current contents of the file at path, if it is the description file of one of their projects.
+// Files that can no longer be read are removed from the index.
+func (w *Workspace) Reindex(path string) {
+	for _, loaded := range w.allPortfolios() {
+		id, ok := projectIDOf(loaded.state.config, path)
+		if !ok {
+			continue
+		}
+
+		// the file is read while holding the lock, so that concurrent changes to it are indexed in order
+		loaded.index.mu.Lock()
+		loaded.index.generation++
+		contents, err := w.Contents(uri.File(path))
+		switch {
+		case loaded.index.projects == nil:
+			// not built yet, it will be read when building the index
+		case err != nil:
+			delete(loaded.index.projects, path)
+		default:
+			loaded.index.projects[path] = indexProject(id, path, contents)
+		}
+		loaded.index.mu.Unlock()
+	}
+}
+
+// SearchSymbols returns the symbols of every project that fuzzily match query, best matches first.
+func (w *Workspace) SearchSymbols(query string) ([]protocol.SymbolInformation, error) {
+	projects, err := w.Projects()
+	if err != nil {
+		return []protocol.SymbolInformation{}, err
+	}
+
+	type match struct {
+		symbol protocol.SymbolInformation
+		score  int
+	}
+	matches := make([]match, 0)
+	for _, project := range projects {
+		for _, symbol := range project.Symbols {
+			score, ok := fuzzyScore(query, symbol.Name)
+			if !ok {
+				continue
+			}
+			matches = append(matches, match{
+				score: score,
+				symbol: protocol.SymbolInformation{
+					Name:          symbol.Name,
+					Kind:          symbol.Kind,
+					ContainerName: symbol.Container,
+					Location:      protocol.Location{URI: uri.File(project.Path), Range: symbol.Range},
+				},
+			})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if matches[i].symbol.Name != matches[j].symbol.Name {
+			return matches[i].symbol.Name < matches[j].symbol.Name
+		}
+		return matches[i].symbol.ContainerName < matches[j].symbol.ContainerName
+	})
+
+	symbols := make([]protocol.SymbolInformation, 0, min(len(matches), maxSymbolResults))
+	for _, m := range matches[:min(len(matches), maxSymbolResults)] {
+		symbols = append(symbols, m.symbol)
+	}
+	return symbols, nil
+}
+
+// fuzzyScore returns how well candidate matches query, if every character of query appears in candidate in the same order, ignoring case.
+// Matches at the start of words and consecutive matches score higher, gaps between matched characters lower the score.
+func fuzzyScore(query string, candidate string) (score int, ok bool) {
+	queryRunes := []rune(strings.ToLower(strings.TrimSpace(query)))
+	candidateRunes := []rune(candidate)
+	if len(queryRunes) == 0 {
+		return 0, true
+	}
+
+	matched, previous := 0, -1
+	for i, r := range candidateRunes {
+		if matched == len(queryRunes) {
+			break
+		}
+		if unicode.ToLower(r) != queryRunes[matched] {
+			continue
+		}
+
+		switch {
+		case i == 0:
+			score += 8
+		case !unicode.IsLetter(candidateRunes[i-1]) && !unicode.IsDigit(candidateRunes[i-1]):
+			score += 5
+		case unicode.IsUpper(r) && unicode.IsLower(candidateRunes[i-1]):
+			score += 5
+		}
+		if previous != -1 {
+			if i == previous+1 {
+				score += 3
+			} else {
+				score -= min(i-previous-1, 3)
+			}
+		}
+		score++
+		previous = i
+		matched++
+	}
+
+	if matched < len(queryRunes) {
+		return 0, false
+	}
+	if len(queryRunes) == len(candidateRunes) {
+		score += 10
+	}
+	return score, true
+}
@@ -0,0 +1,112 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+func TestProjectIDOf(t *testing.T) {
+	config := ortfodb.Configuration{ProjectsDirectory: "/portfolio/projects", ScatteredModeFolder: ".ortfo"}
+	cases := map[string]string{
+		"/portfolio/projects/alpha/description.md":       "alpha",
+		"/portfolio/projects/beta/.ortfo/description.md": "beta",
+		"/portfolio/projects/gamma/docs/description.md":  "",
+		"/portfolio/description.md":                      "",
+		"/portfolio/projects/alpha/README.md":            "",
+	}
+
+	for path, expected := range cases {
+		id, ok := projectIDOf(config, path)
+		if ok != (expected != "") || id != expected {
+			t.Errorf("%s: expected project %q, got %q", path, expected, id)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("wbst", "website"); !ok {
+		t.Errorf("wbst should match website")
+	}
+	if _, ok := fuzzyScore("webz", "website"); ok {
+		t.Errorf("webz should not match website")
+	}
+
+	exact, _ := fuzzyScore("go", "go")
+	prefix, _ := fuzzyScore("go", "golang")
+	scattered, _ := fuzzyScore("go", "a good one")
+	inside, _ := fuzzyScore("go", "mongodb")
+	if !(exact > prefix && prefix > scattered && scattered > inside) {
+		t.Errorf("expected exact (%d) > prefix (%d) > word start (%d) > inside a word (%d)", exact, prefix, scattered, inside)
+	}
+}
+
+func TestProjectsSkipsUnindexablePortfolios(t *testing.T) {
+	root := t.TempDir()
+	workspace := newWorkspace(zap.NewNop())
+	for _, name := range []string{"personal", "studio"} {
+		directory := filepath.Join(root, name)
+		if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := workspace.Load(writePortfolio(t, directory, filepath.Join(directory, "tags.yaml"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	description := filepath.Join(root, "personal", "projects", "site", "description.md")
+	if err := os.MkdirAll(filepath.Dir(description), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(description, []byte("# My site\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the projects of the studio cannot be listed anymore
+	if err := os.RemoveAll(filepath.Join(root, "studio", "projects")); err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := workspace.Projects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].ID != "site" {
+		t.Errorf("expected the projects of the other portfolio, got %+v", projects)
+	}
+}
+
+func TestReindex(t *testing.T) {
+	root := t.TempDir()
+	workspace, err := NewWorkspace(zap.NewNop(), writePortfolio(t, root, filepath.Join(root, "tags.yaml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	description := filepath.Join(root, "projects", "site", "description.md")
+	if err := os.MkdirAll(filepath.Dir(description), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(description, []byte("# My site\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes made before the index is built are read when building it
+	workspace.Open(uri.File(description), 1, "# My portfolio\n")
+	workspace.Reindex(description)
+	if symbols, err := workspace.SearchSymbols("My portfolio"); err != nil || len(symbols) != 1 {
+		t.Errorf("expected the title of the open document to be indexed, got %+v (%v)", symbols, err)
+	}
+
+	// changes made after are indexed right away
+	workspace.Edit(uri.File(description), 2, []protocol.TextDocumentContentChangeEvent{{Text: "# My website\n"}})
+	workspace.Reindex(description)
+	if symbols, err := workspace.SearchSymbols("My website"); err != nil || len(symbols) != 1 {
+		t.Errorf("expected the new title to be indexed, got %+v (%v)", symbols, err)
+	}
+	if symbols, err := workspace.SearchSymbols("My portfolio"); err != nil || len(symbols) != 0 {
+		t.Errorf("expected the old title to be removed from the index, got %+v (%v)", symbols, err)
+	}
+}
@@ -0,0 +1,385 @@
+package languageserver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// maxLayoutLabelLength is the maximum length of the labels of cells in the rendered layout grid, in runes.
+const maxLayoutLabelLength = 24
+
+// layoutReferencePattern matches references to blocks in the layout: p1, m2, l3…
+var layoutReferencePattern = regexp.MustCompile(`^([pml])(\d+)$`)
+
+// blockShorthands maps kinds of blocks the layout can refer to, to the letter used to refer to them.
+var blockShorthands = map[bodyBlockKind]string{
+	blockParagraph: "p",
+	blockMedia:     "m",
+	blockLink:      "l",
+}
+
+// shorthandNames maps letters used in block references to what they refer to.
+var shorthandNames = map[string]string{
+	"p": "paragraph",
+	"m": "media block",
+	"l": "link",
+}
+
+// layoutCell is a cell of the layout, as written in the frontmatter.
+type layoutCell struct {
+	Reference string
+	Node      *yaml.Node
+}
+
+// layoutRow is a row of the layout. Rows written as a single reference have a single cell.
+type layoutRow struct {
+	Node  *yaml.Node
+	Cells []layoutCell
+}
+
+// layoutBlocks are the blocks the layout can refer to in one language, by the letter used to refer to them.
+type layoutBlocks struct {
+	// Language is empty if the description is not split into languages.
+	Language string
+	Blocks   map[string][]bodyBlock
+}
+
+// Layout returns the rows of the layout declared in the frontmatter.
+// ok is false if there is no layout, or if it is not a sequence.
+func (d DescriptionFile) Layout() (rows []layoutRow, ok bool) {
+	layout, ok := d.frontmatterMappings["layout"]
+	if !ok || layout.Kind != yaml.SequenceNode {
+		return nil, false
+	}
+
+	rows = make([]layoutRow, 0, len(layout.Content))
+	for _, row := range layout.Content {
+		switch row.Kind {
+		case yaml.ScalarNode:
+			rows = append(rows, layoutRow{Node: row, Cells: []layoutCell{{Reference: row.Value, Node: row}}})
+		case yaml.SequenceNode:
+			cells := make([]layoutCell, 0, len(row.Content))
+			for _, cell := range row.Content {
+				if cell.Kind == yaml.ScalarNode {
+					cells = append(cells, layoutCell{Reference: cell.Value, Node: cell})
+				}
+			}
+			rows = append(rows, layoutRow{Node: row, Cells: cells})
+		}
+	}
+	return rows, true
+}
+
+// LayoutBlocks returns the blocks the layout can refer to, for every language.
+// Blocks written before the first language marker are shared by all languages, and come first.
+func (d DescriptionFile) LayoutBlocks() []layoutBlocks {
+	sections := d.LanguageSections()
+	shared := []bodyBlock{}
+	if len(sections) > 0 && sections[0].Marker == -1 {
+		shared = sections[0].Blocks
+	}
+
+	languages := make([]layoutBlocks, 0, len(sections))
+	for _, section := range sections {
+		blocks := section.Blocks
+		if section.Marker != -1 {
+			blocks = append(append([]bodyBlock{}, shared...), section.Blocks...)
+		} else if len(sections) > 1 {
+			continue
+		}
+
+		byShorthand := make(map[string][]bodyBlock)
+		for _, block := range blocks {
+			if shorthand, ok := blockShorthands[block.Kind]; ok {
+				byShorthand[shorthand] = append(byShorthand[shorthand], block)
+			}
+		}
+		languages = append(languages, layoutBlocks{Language: section.Language, Blocks: byShorthand})
+	}
+
+	if len(languages) == 0 {
+		languages = append(languages, layoutBlocks{Blocks: map[string][]bodyBlock{}})
+	}
+	return languages
+}
+
+// Resolve returns the block a reference of the layout refers to, if it exists.
+func (l layoutBlocks) Resolve(reference string) (bodyBlock, bool) {
+	match := layoutReferencePattern.FindStringSubmatch(reference)
+	if match == nil {
+		return bodyBlock{}, false
+	}
+
+	index, err := strconv.Atoi(match[2])
+	if err != nil || index < 1 || index > len(l.Blocks[match[1]]) {
+		return bodyBlock{}, false
+	}
+	return l.Blocks[match[1]][index-1], true
+}
+
+// LayoutDiagnostics reports invalid references in the layout, references to blocks that do not exist, rows that do not fit evenly in the grid, and blocks the layout leaves out.
+func (d DescriptionFile) LayoutDiagnostics() []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	rows, ok := d.Layout()
+	if !ok {
+		return diagnostics
+	}
+
+	languages := d.LayoutBlocks()
+	widest := 0
+	for _, row := range rows {
+		widest = max(widest, len(row.Cells))
+	}
+
+	used := make(map[string]bool)
+	for _, row := range rows {
+		if len(row.Cells) == 0 {
+			diagnostics = append(diagnostics, layoutDiagnostic(d.rowRange(row), protocol.DiagnosticSeverityError, "empty-layout-row", "this row has no cells"))
+			continue
+		}
+
+		if widest%len(row.Cells) != 0 {
+			diagnostics = append(diagnostics, layoutDiagnostic(d.rowRange(row), protocol.DiagnosticSeverityWarning, "uneven-layout-row", fmt.Sprintf(
+				"this row has %d cells, which does not evenly divide the %d columns of the widest row: cells will be stretched over a grid of %d columns",
+				len(row.Cells), widest, layoutWidth(rows),
+			)))
+		}
+
+		for _, cell := range row.Cells {
+			match := layoutReferencePattern.FindStringSubmatch(cell.Reference)
+			if match == nil {
+				diagnostics = append(diagnostics, layoutDiagnostic(nodeRange(cell.Node), protocol.DiagnosticSeverityError, "invalid-layout-cell", fmt.Sprintf(
+					"%q is not a block reference: use p, m or l followed by the number of the block, such as p1 or m2",
+					cell.Reference,
+				)))
+				continue
+			}
+
+			index, _ := strconv.Atoi(match[2])
+			used[match[1]+strconv.Itoa(index)] = true
+
+			missing := make([]string, 0)
+			for _, language := range languages {
+				if _, ok := language.Resolve(cell.Reference); ok {
+					continue
+				}
+				count := countOf(len(language.Blocks[match[1]]), shorthandNames[match[1]])
+				if language.Language == "" {
+					missing = append(missing, "the description has "+count)
+				} else {
+					missing = append(missing, language.Language+" has "+count)
+				}
+			}
+			if len(missing) > 0 {
+				diagnostics = append(diagnostics, layoutDiagnostic(nodeRange(cell.Node), protocol.DiagnosticSeverityError, "missing-block", fmt.Sprintf(
+					"no %s %s: %s",
+					shorthandNames[match[1]], cell.Reference, strings.Join(missing, ", "),
+				)))
+			}
+		}
+	}
+
+	reported := make(map[int]bool)
+	for _, language := range languages {
+		for _, shorthand := range []string{"p", "m", "l"} {
+			for i, block := range language.Blocks[shorthand] {
+				reference := shorthand + strconv.Itoa(i+1)
+				if used[reference] || reported[block.Start] {
+					continue
+				}
+				reported[block.Start] = true
+				diagnostics = append(diagnostics, protocol.Diagnostic{
+					Range:    d.linesRange(block.Start, block.Start),
+					Severity: protocol.DiagnosticSeverityWarning,
+					Code:     "unused-block",
+					Source:   diagnosticsSource,
+					Message:  fmt.Sprintf("%s %s is not in the layout, it will not be shown", shorthandNames[shorthand], reference),
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// rowRange returns the range spanning the cells of a row of the layout, or the rest of its line if it has none.
+func (d DescriptionFile) rowRange(row layoutRow) protocol.Range {
+	if len(row.Cells) == 0 {
+		return protocol.Range{Start: positionOf(row.Node), End: endOfLine(d.lines, positionOf(row.Node).Line)}
+	}
+	return protocol.Range{Start: positionOf(row.Node), End: endPositionOf(row.Cells[len(row.Cells)-1].Node)}
+}
+
+func nodeRange(node *yaml.Node) protocol.Range {
+	return protocol.Range{Start: positionOf(node), End: endPositionOf(node)}
+}
+
+func layoutDiagnostic(at protocol.Range, severity protocol.DiagnosticSeverity, code string, message string) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Range:    at,
+		Severity: severity,
+		Code:     code,
+		Source:   diagnosticsSource,
+		Message:  message,
+	}
+}
+
+// countOf returns "no nouns", "1 noun" or "n nouns".
+func countOf(n int, noun string) string {
+	switch n {
+	case 0:
+		return "no " + noun + "s"
+	case 1:
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// layoutWidth returns the number of columns ortfodb normalizes the layout to: the least common multiple of the number of cells of every row.
+func layoutWidth(rows []layoutRow) int {
+	width := 1
+	for _, row := range rows {
+		if len(row.Cells) == 0 {
+			continue
+		}
+		a, b := width, len(row.Cells)
+		for b != 0 {
+			a, b = b, a%b
+		}
+		width = width / a * len(row.Cells)
+	}
+	return width
+}
+
+// LayoutGrid renders the layout as a grid for every language, showing which block goes in each cell.
+// It returns an empty string if there is no layout.
+func (d DescriptionFile) LayoutGrid() string {
+	rows, ok := d.Layout()
+	if !ok {
+		return ""
+	}
+
+	width := layoutWidth(rows)
+	grid := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row.Cells) == 0 {
+			continue
+		}
+		normalized := make([]string, width)
+		for i := range normalized {
+			normalized[i] = row.Cells[i/(width/len(row.Cells))].Reference
+		}
+		grid = append(grid, normalized)
+	}
+	if len(grid) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	for _, language := range d.LayoutBlocks() {
+		if language.Language != "" {
+			fmt.Fprintf(&result, "**%s**\n\n", language.Language)
+		}
+		result.WriteString("```\n")
+		result.WriteString(renderLayoutGrid(grid, func(reference string) string {
+			label := reference
+			if block, ok := language.Resolve(reference); ok {
+				label += " " + block.Name
+			} else if layoutReferencePattern.MatchString(reference) {
+				label += " (missing)"
+			} else {
+				label += " (invalid)"
+			}
+			if runes := []rune(label); len(runes) > maxLayoutLabelLength {
+				label = string(runes[:maxLayoutLabelLength-1]) + "…"
+			}
+			return label
+		}))
+		result.WriteString("```\n\n")
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// renderLayoutGrid draws a normalized layout as an ASCII grid, merging adjacent cells that refer to the same block.
+func renderLayoutGrid(grid [][]string, label func(reference string) string) string {
+	width := len(grid[0])
+	// spans returns the runs of identical cells of a row, as [start, end) column ranges
+	spans := func(row []string) [][2]int {
+		result := make([][2]int, 0)
+		for start := 0; start < width; {
+			end := start + 1
+			for end < width && row[end] == row[start] {
+				end++
+			}
+			result = append(result, [2]int{start, end})
+			start = end
+		}
+		return result
+	}
+
+	// every column is wide enough for the labels of the cells spanning it to fit
+	columnWidth := 2
+	for _, row := range grid {
+		for _, span := range spans(row) {
+			columns := span[1] - span[0]
+			length := len([]rune(label(row[span[0]])))
+			columnWidth = max(columnWidth, (length-3*(columns-1)+columns-1)/columns)
+		}
+	}
+
+	// boundary tells whether there is a vertical line between columns k-1 and k of row
+	boundary := func(row []string, k int) bool {
+		return row != nil && (k == 0 || k == width || row[k-1] != row[k])
+	}
+
+	separator := func(above, below []string) string {
+		var line strings.Builder
+		dashes := func(column int) bool {
+			return column >= 0 && column < width && (above == nil || below == nil || above[column] != below[column])
+		}
+		for k := 0; k <= width; k++ {
+			vertical := boundary(above, k) || boundary(below, k)
+			switch {
+			case (dashes(k-1) || dashes(k)) && vertical:
+				line.WriteString("+")
+			case dashes(k-1) || dashes(k):
+				line.WriteString("-")
+			case vertical:
+				line.WriteString("|")
+			default:
+				line.WriteString(" ")
+			}
+			if k < width {
+				fill := " "
+				if dashes(k) {
+					fill = "-"
+				}
+				line.WriteString(strings.Repeat(fill, columnWidth+2))
+			}
+		}
+		return strings.TrimRight(line.String(), " ") + "\n"
+	}
+
+	var result strings.Builder
+	var previous []string
+	for _, row := range grid {
+		result.WriteString(separator(previous, row))
+		result.WriteString("|")
+		for _, span := range spans(row) {
+			content := columnWidth*(span[1]-span[0]) + 3*(span[1]-span[0]-1)
+			text := ""
+			if previous == nil || previous[span[0]] != row[span[0]] {
+				text = label(row[span[0]])
+			}
+			result.WriteString(" " + text + strings.Repeat(" ", content-len([]rune(text))) + " |")
+		}
+		result.WriteString("\n")
+		previous = row
+	}
+	result.WriteString(separator(previous, nil))
+	return result.String()
+}
@@ -0,0 +1,62 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+const testLayoutDescription = `---
+layout:
+  - [m1, p1]
+  - [p2, x, m3]
+  - l1
+---
+
+![Cover](cover.png)
+
+First paragraph.
+
+Second paragraph.
+
+[Source code](https://example.com)
+
+Left out.
+`
+
+func TestLayoutDiagnostics(t *testing.T) {
+	expected := []struct {
+		line uint32
+		code string
+	}{
+		{2, "uneven-layout-row"},
+		{3, "invalid-layout-cell"},
+		{3, "missing-block"},
+		{15, "unused-block"},
+	}
+
+	diagnostics := ParseDescriptionFile(testLayoutDescription, protocol.Position{}).LayoutDiagnostics()
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %d: %+v", len(expected), len(diagnostics), diagnostics)
+	}
+	for i, e := range expected {
+		if diagnostics[i].Range.Start.Line != e.line || diagnostics[i].Code != e.code {
+			t.Errorf("expected %s on line %d, got %s on line %d: %s", e.code, e.line, diagnostics[i].Code, diagnostics[i].Range.Start.Line, diagnostics[i].Message)
+		}
+	}
+}
+
+func TestLayoutGrid(t *testing.T) {
+	expected := "```\n" + `+-----------------------------------+-----------------------------------+
+| m1 Cover                          | p1 First paragraph.               |
++-----------------------+-----------+-----------+-----------------------+
+| p2 Second paragraph.  | x (invalid)           | m3 (missing)          |
++-----------------------+-----------------------+-----------------------+
+| l1 Source code                                                        |
++-----------------------------------------------------------------------+
+` + "```\n"
+
+	if grid := ParseDescriptionFile(testLayoutDescription, protocol.Position{}).LayoutGrid(); grid != expected {
+		t.Errorf("expected grid\n%s\ngot\n%s", expected, grid)
+	}
+}
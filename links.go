@@ -0,0 +1,137 @@
+package languageserver
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"gopkg.in/yaml.v3"
+)
+
+// markdownLinkPattern matches markdown links: [text](target "title"). Media embeds also match, and are skipped by checking what precedes the match.
+var markdownLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\(\s*([^)\s]*)(?:\s+"[^"]*")?\s*\)`)
+
+// DocumentLinks returns links to the media, local files and web pages the description file refers to.
+// Links to local files have no target until they are resolved, see resolveDocumentLink.
+func (d DescriptionFile) DocumentLinks(s state, workFolder string) []protocol.DocumentLink {
+	links := make([]protocol.DocumentLink, 0)
+	for _, embed := range d.MediaEmbeds() {
+		switch {
+		case embed.Source == "":
+		case embed.IsRemote():
+			links = append(links, protocol.DocumentLink{Range: embed.Range, Target: protocol.DocumentURI(embed.Source)})
+		default:
+			links = append(links, fileLink(embed.Range, embed.Path(workFolder), "Open media"))
+		}
+	}
+
+	d.bodyLines(func(i int, line string) {
+		for _, match := range markdownLinkPattern.FindAllStringSubmatchIndex(line, -1) {
+			if (match[0] > 0 && line[match[0]-1] == '!') || (match[0] == 1 && line[0] == '>') {
+				continue
+			}
+
+			target, _, _ := strings.Cut(line[match[4]:match[5]], "#")
+			if target == "" {
+				continue
+			}
+			if parsed, err := url.Parse(target); err != nil || parsed.Scheme != "" {
+				continue
+			}
+
+			links = append(links, fileLink(protocol.Range{
+				Start: protocol.Position{Line: uint32(i), Character: uint32(utf16Len(line[:match[4]]))},
+				End:   protocol.Position{Line: uint32(i), Character: uint32(utf16Len(line[:match[5]]))},
+			}, workFilePath(workFolder, target), "Open file"))
+		}
+	})
+
+	if thumbnail, ok := d.frontmatterMappings["thumbnail"]; ok && thumbnail.Kind == yaml.ScalarNode && thumbnail.Value != "" {
+		links = append(links, fileLink(
+			protocol.Range{Start: positionOf(&thumbnail), End: endPositionOf(&thumbnail)},
+			workFilePath(workFolder, thumbnail.Value),
+			"Open thumbnail",
+		))
+	}
+
+	if tags, ok := d.frontmatterMappings["tags"]; ok {
+		links = append(links, learnMoreAtLinks[ortfodb.Tag]("tag", &tags, s.tags)...)
+	}
+	if technologies, ok := d.frontmatterMappings["made with"]; ok {
+		links = append(links, learnMoreAtLinks[ortfodb.Technology]("technology", &technologies, s.technologies)...)
+	}
+	return links
+}
+
+// learnMoreAtLinks returns links to the "learn more at" URL of every entry of repo that an item of sequence refers to.
+func learnMoreAtLinks[T referrable](kind string, sequence *yaml.Node, repo []yaml.Node) []protocol.DocumentLink {
+	links := make([]protocol.DocumentLink, 0)
+	if sequence.Kind != yaml.SequenceNode {
+		return links
+	}
+
+	for _, item := range sequence.Content {
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+
+		_, entry, err := FindInRepository[T](item.Value, kind, repo)
+		if err != nil || learnMoreAtOf(*entry) == "" {
+			continue
+		}
+
+		links = append(links, protocol.DocumentLink{
+			Range:   protocol.Range{Start: positionOf(item), End: endPositionOf(item)},
+			Target:  protocol.DocumentURI(learnMoreAtOf(*entry)),
+			Tooltip: fmt.Sprintf("Learn more about %s", (*entry).DisplayName()),
+		})
+	}
+	return links
+}
+
+// learnMoreAtOf returns the URL where more information can be found about item.
+func learnMoreAtOf(item referrable) string {
+	switch item := item.(type) {
+	case ortfodb.Tag:
+		return item.LearnMoreAt
+	case ortfodb.Technology:
+		return item.LearnMoreAt
+	}
+	return ""
+}
+
+// fileLink returns a link to the local file at path, to be resolved by resolveDocumentLink.
+func fileLink(at protocol.Range, path string, tooltip string) protocol.DocumentLink {
+	return protocol.DocumentLink{
+		Range:   at,
+		Tooltip: tooltip,
+		Data:    map[string]interface{}{"path": path},
+	}
+}
+
+// resolveDocumentLink sets the target of a link to a local file, after checking that the file exists.
+// Links to files that cannot be opened are left without a target, their tooltip saying why.
+func resolveDocumentLink(link protocol.DocumentLink) protocol.DocumentLink {
+	data, ok := link.Data.(map[string]interface{})
+	if !ok {
+		return link
+	}
+
+	path, _ := data["path"].(string)
+	if path == "" {
+		return link
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		link.Tooltip = fmt.Sprintf("Cannot open %s", path)
+		return link
+	}
+
+	link.Target = uri.File(path)
+	return link
+}
@@ -0,0 +1,77 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestDocumentLinks(t *testing.T) {
+	tags, err := ParseRepository([]byte("- singular: website\n  plural: websites\n  learn more at: https://example.com/websites\n- singular: app\n  plural: apps\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := ParseDescriptionFile(`---
+thumbnail: cover%20art.png
+tags: [websites, app, unknown]
+---
+
+![Cover](cover.png) ![Remote](https://example.com/image.png)
+
+See [the notes](notes/é.md#usage), [the site](https://example.com), [above](#top) and ![](demo.mp4).
+`, protocol.Position{})
+
+	expected := []struct {
+		at      protocol.Range
+		target  protocol.DocumentURI
+		path    string
+		tooltip string
+	}{
+		{at: span(5, 9, 5, 18), path: filepath.Join("/work", "cover.png"), tooltip: "Open media"},
+		{at: span(5, 30, 5, 59), target: "https://example.com/image.png"},
+		{at: span(7, 90, 7, 98), path: filepath.Join("/work", "demo.mp4"), tooltip: "Open media"},
+		// é is one UTF-16 code unit
+		{at: span(7, 16, 7, 32), path: filepath.Join("/work", "notes", "é.md"), tooltip: "Open file"},
+		{at: span(1, 11, 1, 26), path: filepath.Join("/work", "cover art.png"), tooltip: "Open thumbnail"},
+		{at: span(2, 7, 2, 15), target: "https://example.com/websites", tooltip: "Learn more about websites"},
+	}
+
+	links := file.DocumentLinks(state{tags: tags}, "/work")
+	if len(links) != len(expected) {
+		t.Fatalf("expected %d links, got %d: %+v", len(expected), len(links), links)
+	}
+	for i, e := range expected {
+		link := links[i]
+		path := ""
+		if data, ok := link.Data.(map[string]interface{}); ok {
+			path, _ = data["path"].(string)
+		}
+		if link.Range != e.at || link.Target != e.target || path != e.path || link.Tooltip != e.tooltip {
+			t.Errorf("link %d: expected %v to %q%q (%q), got %v to %q%q (%q)", i, e.at, e.target, e.path, e.tooltip, link.Range, link.Target, path, link.Tooltip)
+		}
+	}
+}
+
+func TestResolveDocumentLink(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(existing, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if resolved := resolveDocumentLink(fileLink(span(0, 0, 0, 5), existing, "Open file")); resolved.Target != uri.File(existing) {
+		t.Errorf("expected a link to %s, got %q", existing, resolved.Target)
+	}
+
+	if resolved := resolveDocumentLink(fileLink(span(0, 0, 0, 5), existing+".missing", "Open file")); resolved.Target != "" || resolved.Range != span(0, 0, 0, 5) {
+		t.Errorf("expected a link without a target for a missing file, got %+v", resolved)
+	}
+
+	remote := protocol.DocumentLink{Range: span(0, 0, 0, 5), Target: "https://example.com"}
+	if resolved := resolveDocumentLink(remote); resolved.Target != remote.Target {
+		t.Errorf("expected links that are not to local files to be left as is, got %q", resolved.Target)
+	}
+}
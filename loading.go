@@ -0,0 +1,166 @@
+package languageserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+// loadError is an error that prevented a portfolio from being loaded, located in the file that caused it.
+type loadError struct {
+	// path is the file that could not be loaded: the ortfodb configuration or one of the repositories.
+	path     string
+	position protocol.Position
+	err      error
+}
+
+func (e *loadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *loadError) Unwrap() error {
+	return e.err
+}
+
+// newLoadError returns a load error for the file at path, positioned on the line reported by the YAML parser, if err comes from it.
+func newLoadError(path string, err error) *loadError {
+	return &loadError{path: path, position: yamlErrorPosition(err), err: err}
+}
+
+// loadConfiguration loads the ortfodb configuration at configPath, with its paths resolved against the directory containing it.
+// It is validated beforehand, since ortfodb prints validation errors to the standard output, which is used to talk to the client.
+func loadConfiguration(configPath string) (ortfodb.Configuration, error) {
+	contents, err := os.ReadFile(configPath)
+	if err != nil {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("while reading ortfodb configuration: %w", err))
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("while parsing ortfodb configuration %s: %w", configPath, err))
+	}
+
+	valid, validationErrors, err := ortfodb.ValidateConfiguration(configPath)
+	if err != nil {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("while validating ortfodb configuration %s: %w", configPath, err))
+	}
+	if !valid {
+		problems := make([]string, 0, len(validationErrors))
+		for _, validationErr := range validationErrors {
+			problems = append(problems, validationErr.String())
+		}
+		loadErr := newLoadError(configPath, fmt.Errorf("invalid ortfodb configuration %s: %s", configPath, strings.Join(problems, "; ")))
+		if len(document.Content) > 0 {
+			// point to the first problem that is about a field written in the file, missing ones are reported on the first line
+			root := document.Content[0]
+			loadErr.position = positionOf(root)
+			for _, validationErr := range validationErrors {
+				if node := configurationNode(root, validationErr.Field()); node != root {
+					loadErr.position = positionOf(node)
+					break
+				}
+			}
+		}
+		return ortfodb.Configuration{}, loadErr
+	}
+
+	var config ortfodb.Configuration
+	if err := ortfodb.LoadConfiguration(configPath, &config); err != nil {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("while loading ortfodb configuration from %s: %w", configPath, err))
+	}
+	if err := resolveConfigurationPaths(&config, filepath.Dir(configPath)); err != nil {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("while resolving paths of ortfodb configuration %s: %w", configPath, err))
+	}
+
+	if stat, err := os.Stat(config.ProjectsDirectory); err != nil {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("while checking projects directory of ortfodb configuration %s: %w", configPath, err))
+	} else if !stat.IsDir() {
+		return ortfodb.Configuration{}, newLoadError(configPath, fmt.Errorf("projects directory %s is not a directory", config.ProjectsDirectory))
+	}
+
+	if config.ScatteredModeFolder == "" {
+		config.ScatteredModeFolder = ".ortfo"
+	}
+	config.ScatteredModeFolder = strings.TrimRight(config.ScatteredModeFolder, "/\\")
+	return config, nil
+}
+
+// resolveConfigurationPaths expands ~ in the paths of the configuration the language server uses, and makes relative ones relative to directory, the one containing the configuration file.
+// This is done instead of using ortfodb.NewConfiguration, which resolves them against the working directory: ortfodb is meant to be run from the portfolio, unlike the language server.
+func resolveConfigurationPaths(config *ortfodb.Configuration, directory string) error {
+	for _, path := range []*string{&config.ProjectsDirectory, &config.Tags.Repository, &config.Technologies.Repository} {
+		if *path == "" {
+			continue
+		}
+		if *path == "~" || strings.HasPrefix(*path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("while expanding ~ in %s: %w", *path, err)
+			}
+			*path = filepath.Join(home, strings.TrimPrefix(*path, "~"))
+		}
+		if !filepath.IsAbs(*path) {
+			*path = filepath.Join(directory, *path)
+		}
+	}
+	return nil
+}
+
+// configurationNode returns the node of the configuration at the given dot-separated field path, as reported by JSON schema validation.
+// The deepest node of the path that exists is returned.
+func configurationNode(root *yaml.Node, field string) *yaml.Node {
+	node := root
+	for _, name := range strings.Split(field, ".") {
+		child, ok := fieldOf(node, name)
+		if !ok {
+			break
+		}
+		node = child
+	}
+	return node
+}
+
+// diagnostic returns the diagnostic reporting the error on the file that caused it, whose contents are given.
+func (e *loadError) diagnostic(contents string) protocol.Diagnostic {
+	return errorDiagnosticAt(e.position, e, strings.Split(contents, "\n"), "invalid-portfolio")
+}
+
+// loadErrorDiagnostics returns a diagnostic for every portfolio that could not be loaded because of the file at documentURI.
+func (h Handler) loadErrorDiagnostics(documentURI protocol.URI) []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	for _, loadErr := range h.Workspace.loadErrors() {
+		if !samePath(loadErr.path, documentURI.Filename()) {
+			continue
+		}
+
+		contents, _ := h.Workspace.Contents(documentURI)
+		diagnostics = append(diagnostics, loadErr.diagnostic(contents))
+	}
+	return diagnostics
+}
+
+// reportLoadErrors shows the errors that prevented portfolios from being loaded to the user, and publishes them as diagnostics on the files that caused them.
+func (h Handler) reportLoadErrors(ctx context.Context, err error) error {
+	var errs error
+	for _, failure := range multierr.Errors(err) {
+		errs = multierr.Append(errs, h.Client.ShowMessage(ctx, &protocol.ShowMessageParams{
+			Type:    protocol.MessageTypeError,
+			Message: fmt.Sprintf("ortfols: %s. Tags and technologies will not be checked until this is fixed.", failure),
+		}))
+
+		var loadErr *loadError
+		if errors.As(failure, &loadErr) {
+			errs = multierr.Append(errs, h.PublishDiagnostics(ctx, uri.File(loadErr.path)))
+		}
+	}
+	return errs
+}
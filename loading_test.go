@@ -0,0 +1,113 @@
+package languageserver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+func TestLoadConfigurationErrors(t *testing.T) {
+	directory := t.TempDir()
+	configPath := filepath.Join(directory, ConfigurationFilename)
+	for contents, expected := range map[string]protocol.Position{
+		"projects at: projects\ntags: [\n":                                {Line: 1},
+		"projects at: projects\nscattered mode folder: .ortfo\ntags: 3\n": {Line: 2, Character: 6},
+	} {
+		if err := os.WriteFile(configPath, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var loadErr *loadError
+		if _, err := loadConfiguration(configPath); !errors.As(err, &loadErr) {
+			t.Errorf("%q: expected a load error, got %v", contents, err)
+			continue
+		}
+		if loadErr.path != configPath || loadErr.position != expected {
+			t.Errorf("%q: expected error at %s:%v, got %s:%v (%s)", contents, configPath, expected, loadErr.path, loadErr.position, loadErr)
+		}
+	}
+}
+
+func TestWorkspaceRecoversFromLoadErrors(t *testing.T) {
+	root := t.TempDir()
+	tagsRepository := filepath.Join(root, "tags.yaml")
+	configPath := writePortfolio(t, root, tagsRepository)
+	if err := os.WriteFile(tagsRepository, []byte("- singular: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace := newWorkspace(zap.NewNop())
+	if err := workspace.Load(configPath); err == nil {
+		t.Fatalf("expected the broken tags repository to prevent loading")
+	}
+	if current := workspace.StateOf(uri.File(configPath)); !current.degraded || current.config.Tags.Repository != tagsRepository {
+		t.Errorf("expected a degraded state with the configuration loaded, got %+v", current)
+	}
+	if failed := workspace.failedPortfoliosLoadedFrom(uri.File(tagsRepository)); len(failed) != 1 {
+		t.Errorf("expected the portfolio to be reloaded when the tags repository changes, got %v", failed)
+	}
+
+	if err := os.WriteFile(tagsRepository, []byte("- singular: web\n  plural: webs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.Reload(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if current := workspace.StateOf(uri.File(configPath)); current.degraded || len(current.tags) != 1 || len(workspace.loadErrors()) != 0 {
+		t.Errorf("expected the portfolio to be loaded once fixed, got %+v", current)
+	}
+}
+
+func TestLoadConfigurationRelativePaths(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "projects", "alpha")
+	if err := os.MkdirAll(project, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for path, contents := range map[string]string{
+		filepath.Join(root, ConfigurationFilename):               "projects at: projects\nscattered mode folder: .ortfo\ntags:\n  repository: tags.yaml\ntechnologies:\n  repository: ./repositories/technologies.yaml\n",
+		filepath.Join(root, "tags.yaml"):                         "- singular: web\n  plural: webs\n",
+		filepath.Join(root, "repositories", "technologies.yaml"): "- slug: go\n  name: Go\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(workingDirectory) })
+
+	configPath, ok := configurationAbove(project)
+	if !ok {
+		t.Fatalf("expected a configuration above %s", project)
+	}
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := workspace.StateOf(uri.File(filepath.Join(project, "description.md")))
+	if loaded.config.ProjectsDirectory != filepath.Join(root, "projects") {
+		t.Errorf("expected projects directory %s, got %s", filepath.Join(root, "projects"), loaded.config.ProjectsDirectory)
+	}
+	if len(loaded.tags) != 1 || len(loaded.technologies) != 1 {
+		t.Errorf("expected repositories next to the configuration to be loaded, got %d tags and %d technologies", len(loaded.tags), len(loaded.technologies))
+	}
+	if loadedFrom := workspace.portfoliosLoadedFrom(uri.File(filepath.Join(root, "repositories", "technologies.yaml"))); len(loadedFrom) != 1 {
+		t.Errorf("expected the portfolio to be loaded from its technologies repository, got %v", loadedFrom)
+	}
+}
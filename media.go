@@ -0,0 +1,206 @@
+package languageserver
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.lsp.dev/protocol"
+)
+
+// mediaEmbedPattern matches media embeds: ![alt "title"](source), or >[alt "title"](source) at the start of a line.
+// The title can also be written after the source, as in regular markdown images.
+var mediaEmbedPattern = regexp.MustCompile(`(!|^>)\[([^\]]*)\]\(\s*([^)\s]*)(?:\s+"([^"]*)")?\s*\)`)
+
+// titleInAlt matches alt texts that end with a quoted title, as in ![alt "title"](source).
+var titleInAlt = regexp.MustCompile(`^(.*?)\s*"([^"]*)"\s*$`)
+
+// mediaSourceBeforeCursor matches the text before the cursor when the cursor is inside the source of a media embed.
+var mediaSourceBeforeCursor = regexp.MustCompile(`(!|^>)\[[^\]]*\]\(\s*([^)\s]*)$`)
+
+// codeFence matches lines that open or close a fenced code block.
+var codeFence = regexp.MustCompile("^\\s*(```|~~~)")
+
+// mediaEmbed is a media embedded in the body of a description file.
+type mediaEmbed struct {
+	Alt    string
+	Title  string
+	Source string
+	// Range is where the source is written.
+	Range protocol.Range
+	// Span is where the whole embed is written.
+	Span protocol.Range
+}
+
+// IsRemote is true if the source of the media is a URL instead of a file path.
+func (m mediaEmbed) IsRemote() bool {
+	parsed, err := url.Parse(m.Source)
+	return err == nil && parsed.Scheme != "" && parsed.Host != ""
+}
+
+// Path returns the path to the media file, given the folder of the work it is embedded in.
+func (m mediaEmbed) Path(workFolder string) string {
+	return workFilePath(workFolder, m.Source)
+}
+
+// workFilePath returns the path to the file that source, a possibly URL-encoded path relative to workFolder, refers to.
+func workFilePath(workFolder string, source string) string {
+	if unescaped, err := url.PathUnescape(source); err == nil {
+		source = unescaped
+	}
+	if filepath.IsAbs(source) {
+		return source
+	}
+	return filepath.Join(workFolder, filepath.FromSlash(source))
+}
+
+// bodyStartsAt returns the line the body of the description file starts at, right after the frontmatter.
+func (d DescriptionFile) bodyStartsAt() int {
+	if d.frontmatterEndsAt.Line == 0 {
+		return 0
+	}
+	return int(d.frontmatterEndsAt.Line) + 1
+}
+
+// bodyLines calls do on every line of the body of the description file that is not inside a fenced code block.
+func (d DescriptionFile) bodyLines(do func(i int, line string)) {
+	inCodeBlock := false
+	for i := d.bodyStartsAt(); i < len(d.lines); i++ {
+		line := strings.TrimSuffix(d.lines[i], "\r")
+		if codeFence.MatchString(line) {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if !inCodeBlock {
+			do(i, line)
+		}
+	}
+}
+
+// MediaEmbeds returns every media embedded in the body of the description file, outside of code blocks.
+func (d DescriptionFile) MediaEmbeds() []mediaEmbed {
+	embeds := make([]mediaEmbed, 0)
+	d.bodyLines(func(i int, line string) {
+		for _, match := range mediaEmbedPattern.FindAllStringSubmatchIndex(line, -1) {
+			embed := mediaEmbed{
+				Alt:    line[match[4]:match[5]],
+				Source: line[match[6]:match[7]],
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(i), Character: uint32(utf16Len(line[:match[6]]))},
+					End:   protocol.Position{Line: uint32(i), Character: uint32(utf16Len(line[:match[7]]))},
+				},
+				Span: protocol.Range{
+					Start: protocol.Position{Line: uint32(i), Character: uint32(utf16Len(line[:match[0]]))},
+					End:   protocol.Position{Line: uint32(i), Character: uint32(utf16Len(line[:match[1]]))},
+				},
+			}
+			if match[8] != -1 {
+				embed.Title = line[match[8]:match[9]]
+			} else if parts := titleInAlt.FindStringSubmatch(embed.Alt); parts != nil {
+				embed.Alt, embed.Title = parts[1], parts[2]
+			}
+			embeds = append(embeds, embed)
+		}
+	})
+	return embeds
+}
+
+// MediaEmbedAtCursor returns the media embed the cursor is on.
+func (d DescriptionFile) MediaEmbedAtCursor() (mediaEmbed, bool) {
+	for _, embed := range d.MediaEmbeds() {
+		if !isAfter(embed.Span.Start, d.cursor) && !isAfter(d.cursor, embed.Span.End) {
+			return embed, true
+		}
+	}
+	return mediaEmbed{}, false
+}
+
+// MediaDiagnostics reports media embeds whose file does not exist in workFolder.
+func (d DescriptionFile) MediaDiagnostics(workFolder string) []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	for _, embed := range d.MediaEmbeds() {
+		if embed.Source == "" || embed.IsRemote() {
+			continue
+		}
+
+		if _, err := os.Stat(embed.Path(workFolder)); err == nil {
+			continue
+		}
+
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Range:    embed.Range,
+			Severity: protocol.DiagnosticSeverityError,
+			Code:     "missing-media",
+			Source:   diagnosticsSource,
+			Message:  fmt.Sprintf("media file %q not found in %s", embed.Source, workFolder),
+		})
+	}
+	return diagnostics
+}
+
+// MediaCompletionItems returns completion items for files of workFolder, if the cursor is inside the source of a media embed.
+func (d DescriptionFile) MediaCompletionItems(workFolder string) ([]protocol.CompletionItem, bool) {
+	if int(d.cursor.Line) >= len(d.lines) || int(d.cursor.Line) < d.bodyStartsAt() {
+		return nil, false
+	}
+
+	line := strings.TrimSuffix(d.CurrentLine(), "\r")
+	cursor := utf16ColumnToByte(line, d.cursor.Character)
+	match := mediaSourceBeforeCursor.FindStringSubmatch(line[:cursor])
+	if match == nil {
+		return nil, false
+	}
+
+	typed := match[2]
+	directory, prefix := "", typed
+	if slash := strings.LastIndex(typed, "/"); slash != -1 {
+		directory, prefix = typed[:slash+1], typed[slash+1:]
+	}
+
+	// replace the whole path segment under the cursor, including what is after it
+	end := cursor + strings.IndexAny(line[cursor:]+")", ")/ \t")
+	replace := protocol.Range{
+		Start: protocol.Position{Line: d.cursor.Line, Character: uint32(utf16Len(line[:cursor-len(prefix)]))},
+		End:   protocol.Position{Line: d.cursor.Line, Character: uint32(utf16Len(line[:end]))},
+	}
+
+	if unescaped, err := url.PathUnescape(directory); err == nil {
+		directory = unescaped
+	}
+
+	entries, err := os.ReadDir(filepath.Join(workFolder, filepath.FromSlash(directory)))
+	if err != nil {
+		return []protocol.CompletionItem{}, true
+	}
+
+	items := make([]protocol.CompletionItem, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+
+		item := protocol.CompletionItem{
+			Label:    entry.Name(),
+			Kind:     protocol.CompletionItemKindFile,
+			SortText: "1" + entry.Name(),
+			TextEdit: &protocol.TextEdit{Range: replace, NewText: escapeMediaSource(entry.Name())},
+		}
+		if entry.IsDir() {
+			item.Label += "/"
+			item.Kind = protocol.CompletionItemKindFolder
+			item.SortText = "0" + entry.Name()
+			item.TextEdit.NewText += "/"
+			item.Command = &protocol.Command{Title: "Suggest files", Command: "editor.action.triggerSuggest"}
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// escapeMediaSource escapes characters of a file name that would end the source of a media embed.
+func escapeMediaSource(name string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(name)
+}
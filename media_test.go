@@ -0,0 +1,44 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestMediaEmbeds(t *testing.T) {
+	file := ParseDescriptionFile(`---
+thumbnail: cover.png
+---
+
+![Cover "The cover"](cover.png) and ![](img/é.jpg "Photo")
+
+>[Demo](demo.mp4)
+
+`+"```"+`
+![Not a media](code.png)
+`+"```"+`
+`, protocol.Position{})
+
+	expected := []struct {
+		source string
+		title  string
+		start  uint32
+		end    uint32
+	}{
+		{"cover.png", "The cover", 21, 30},
+		{"img/é.jpg", "Photo", 40, 49},
+		{"demo.mp4", "", 8, 16},
+	}
+
+	embeds := file.MediaEmbeds()
+	if len(embeds) != len(expected) {
+		t.Fatalf("expected %d media embeds, got %d: %v", len(expected), len(embeds), embeds)
+	}
+	for i, e := range expected {
+		embed := embeds[i]
+		if embed.Source != e.source || embed.Title != e.title || embed.Range.Start.Character != e.start || embed.Range.End.Character != e.end {
+			t.Errorf("expected %q %q at %d-%d, got %q %q at %d-%d", e.source, e.title, e.start, e.end, embed.Source, embed.Title, embed.Range.Start.Character, embed.Range.End.Character)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package languageserver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/tcolgate/mp3"
+	"go.lsp.dev/uri"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// mediaInfo is what can be known about a media file without decoding it entirely.
+type mediaInfo struct {
+	ContentType string
+	// Size is the size of the file, in bytes.
+	Size int64
+	// Width and Height are the dimensions of images, in pixels. They are zero if unknown.
+	Width  int
+	Height int
+	// Duration is the duration of audio and video files. It is zero if unknown.
+	Duration time.Duration
+}
+
+// analyzeMedia reads information about the media file at path.
+// Dimensions and durations are only read for formats that have a pure-Go decoder.
+func analyzeMedia(path string) (mediaInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return mediaInfo{}, fmt.Errorf("while getting information on %s: %w", path, err)
+	}
+	if stat.IsDir() {
+		return mediaInfo{}, fmt.Errorf("%s is a directory", path)
+	}
+
+	contentType, err := mimetype.DetectFile(path)
+	if err != nil {
+		return mediaInfo{}, fmt.Errorf("while detecting content type of %s: %w", path, err)
+	}
+
+	info := mediaInfo{
+		ContentType: contentType.String(),
+		Size:        stat.Size(),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return info, fmt.Errorf("while opening %s: %w", path, err)
+	}
+	defer file.Close()
+
+	switch {
+	case contentType.Is("image/svg+xml"):
+	case strings.HasPrefix(info.ContentType, "image/"):
+		config, _, err := image.DecodeConfig(file)
+		if err != nil {
+			return info, fmt.Errorf("while reading dimensions of %s: %w", path, err)
+		}
+		info.Width, info.Height = config.Width, config.Height
+	case contentType.Is("audio/mpeg"):
+		info.Duration, err = mp3Duration(file, info.Size)
+	case contentType.Is("audio/wav"):
+		info.Duration, err = wavDuration(file)
+	case contentType.Is("video/mp4"), contentType.Is("video/quicktime"), contentType.Is("audio/mp4"), contentType.Is("video/x-m4v"), contentType.Is("audio/x-m4a"):
+		info.Duration, err = mp4Duration(file)
+	}
+	if err != nil {
+		return info, fmt.Errorf("while reading duration of %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// mp3Duration reads the duration of an MP3 file of the given size from its first frame, without decoding the others.
+// The number of frames is read from the Xing or VBRI header of variable bit rate files; for other files, the duration is estimated from the bit rate of the first frame.
+func mp3Duration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	start, err := id3v2Size(r)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var (
+		frame   mp3.Frame
+		skipped int
+	)
+	if err := mp3.NewDecoder(r).Decode(&frame, &skipped); err != nil {
+		return 0, fmt.Errorf("no MP3 frame: %w", err)
+	}
+	start += int64(skipped)
+
+	header := frame.Header()
+	contents, err := io.ReadAll(frame.Reader())
+	if err != nil {
+		return 0, err
+	}
+	if frames, ok := vbrFrames(contents, &frame); ok {
+		return time.Duration(float64(frames) * float64(frame.Samples()) / float64(header.SampleRate()) * float64(time.Second)), nil
+	}
+	return time.Duration(float64(size-start) * 8 / float64(header.BitRate()) * float64(time.Second)), nil
+}
+
+// id3v2Size returns the size of the ID3v2 tag at the start of r, or 0 if there is none.
+func id3v2Size(r io.Reader) (int64, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, fmt.Errorf("file is too short: %w", err)
+	}
+	if string(header[0:3]) != "ID3" {
+		return 0, nil
+	}
+
+	// the size is a syncsafe integer: 7 bits per byte
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	size += 10
+	if header[5]&0x10 != 0 {
+		// footer
+		size += 10
+	}
+	return size, nil
+}
+
+// vbrFrames returns the number of frames of a variable bit rate MP3 file, from the Xing (or Info) or VBRI header held by its first frame.
+func vbrFrames(contents []byte, frame *mp3.Frame) (uint32, bool) {
+	xingAt := 4
+	if frame.Header().Protection() {
+		xingAt += 2
+	}
+	if sideInfo, err := frame.SideInfoLength(); err == nil {
+		xingAt += sideInfo
+	}
+	if len(contents) >= xingAt+12 {
+		tag := string(contents[xingAt : xingAt+4])
+		flags := binary.BigEndian.Uint32(contents[xingAt+4 : xingAt+8])
+		if (tag == "Xing" || tag == "Info") && flags&0x1 != 0 {
+			return binary.BigEndian.Uint32(contents[xingAt+8 : xingAt+12]), true
+		}
+	}
+
+	// the VBRI header always comes 32 bytes after the frame header
+	const vbriAt = 4 + 32
+	if len(contents) >= vbriAt+18 && string(contents[vbriAt:vbriAt+4]) == "VBRI" {
+		return binary.BigEndian.Uint32(contents[vbriAt+14 : vbriAt+18]), true
+	}
+	return 0, false
+}
+
+// wavDuration computes the duration of a WAV file from the byte rate of its fmt chunk and the size of its data chunk.
+func wavDuration(r io.Reader) (time.Duration, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, fmt.Errorf("not a RIFF/WAVE file")
+	}
+
+	byteRate := uint32(0)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return 0, fmt.Errorf("no data chunk: %w", err)
+		}
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			format := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return 0, err
+			}
+			if len(format) < 12 {
+				return 0, fmt.Errorf("fmt chunk is too short")
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, fmt.Errorf("data chunk comes before fmt chunk")
+			}
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second)), nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+// mp4Duration reads the duration of an ISO base media file (MP4, MOV, M4A…) from its movie header box.
+func mp4Duration(r io.ReadSeeker) (time.Duration, error) {
+	moov, err := findBox(r, "moov", -1)
+	if err != nil {
+		return 0, err
+	}
+
+	mvhd, err := findBox(r, "mvhd", moov)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, min(mvhd, 32))
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+	if len(header) == 0 {
+		return 0, fmt.Errorf("mvhd box is empty")
+	}
+
+	var timescale, duration uint64
+	switch header[0] {
+	case 0:
+		if len(header) < 20 {
+			return 0, fmt.Errorf("mvhd box is too short")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(header[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(header[16:20]))
+	case 1:
+		if len(header) < 32 {
+			return 0, fmt.Errorf("mvhd box is too short")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(header[20:24]))
+		duration = binary.BigEndian.Uint64(header[24:32])
+	default:
+		return 0, fmt.Errorf("unsupported mvhd version %d", header[0])
+	}
+
+	if timescale == 0 {
+		return 0, fmt.Errorf("mvhd box has a timescale of zero")
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// findBox seeks r to the contents of the first box of the given type, among the boxes found in the next within bytes (or until the end of r if within is negative).
+// It returns the size of the contents of the box.
+func findBox(r io.ReadSeeker, boxType string, within int64) (int64, error) {
+	for read := int64(0); within < 0 || read < within; {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return 0, fmt.Errorf("no %s box: %w", boxType, err)
+		}
+		size, headerSize := int64(binary.BigEndian.Uint32(header[0:4])), int64(8)
+		if size == 1 {
+			var largeSize [8]byte
+			if _, err := io.ReadFull(r, largeSize[:]); err != nil {
+				return 0, err
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(largeSize[:])), 16
+		}
+		if size == 0 {
+			// the box extends to the end of the file
+			current, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return 0, err
+			}
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := r.Seek(current, io.SeekStart); err != nil {
+				return 0, err
+			}
+			size = end - current + headerSize
+		}
+		if size < headerSize {
+			return 0, fmt.Errorf("invalid size for box %q", header[4:8])
+		}
+
+		if string(header[4:8]) == boxType {
+			return size - headerSize, nil
+		}
+
+		if _, err := r.Seek(size-headerSize, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+		read += size
+	}
+	return 0, fmt.Errorf("no %s box", boxType)
+}
+
+// Description returns a markdown description of the media, as shown on hover.
+// path is the path to the media file, used to preview images.
+func (m mediaInfo) Description(embed mediaEmbed, path string) string {
+	var description strings.Builder
+	if strings.HasPrefix(m.ContentType, "image/") {
+		fmt.Fprintf(&description, "![%s](%s)\n\n", embed.Alt, uri.File(path))
+	}
+
+	if embed.Title != "" {
+		fmt.Fprintf(&description, "**%s**\n\n", embed.Title)
+	}
+
+	details := []string{fmt.Sprintf("`%s`", m.ContentType)}
+	if m.Width > 0 && m.Height > 0 {
+		details = append(details, fmt.Sprintf("%d × %d px", m.Width, m.Height))
+	}
+	if m.Duration > 0 {
+		details = append(details, formatDuration(m.Duration))
+	}
+	details = append(details, formatSize(m.Size))
+	description.WriteString(strings.Join(details, " · "))
+	return description.String()
+}
+
+// formatDuration formats d as [h:]mm:ss.
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// formatSize formats a size in bytes with a human-readable unit.
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,96 @@
+package languageserver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func box(boxType string, contents ...[]byte) []byte {
+	body := bytes.Join(contents, nil)
+	header := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(header, boxType...), body...)
+}
+
+func TestMP4Duration(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:20], 83500)
+	file := bytes.Join([][]byte{
+		box("ftyp", []byte("isom")),
+		box("free"),
+		box("moov", box("mvhd", mvhd), box("trak")),
+	}, nil)
+
+	duration, err := mp4Duration(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("could not read duration: %s", err)
+	}
+	if duration != 83500*time.Millisecond {
+		t.Errorf("expected a duration of 1m23.5s, got %s", duration)
+	}
+}
+
+func TestWAVDuration(t *testing.T) {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint32(format[8:12], 44100*2*2)
+	file := []byte("RIFF\x00\x00\x00\x00WAVE")
+	file = append(append(append(file, "fmt "...), binary.LittleEndian.AppendUint32(nil, 16)...), format...)
+	file = append(append(file, "data"...), binary.LittleEndian.AppendUint32(nil, 44100*2*2*3)...)
+
+	duration, err := wavDuration(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("could not read duration: %s", err)
+	}
+	if duration != 3*time.Second {
+		t.Errorf("expected a duration of 3s, got %s", duration)
+	}
+}
+
+func TestMP3Duration(t *testing.T) {
+	// MPEG-1 layer III frames at 128 kbit/s and 44.1 kHz, in stereo: 417 bytes and 1152 samples each
+	frame := func(contents ...[]byte) []byte {
+		data := make([]byte, 417)
+		copy(data, []byte{0xFF, 0xFB, 0x90, 0x00})
+		for _, part := range contents {
+			copy(data[4+32:], part)
+		}
+		return data
+	}
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x14"), make([]byte, 20)...)
+	xing := append([]byte("Xing"), append(binary.BigEndian.AppendUint32(nil, 0x1), binary.BigEndian.AppendUint32(nil, 1000)...)...)
+	vbri := append([]byte("VBRI\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00"), binary.BigEndian.AppendUint32(nil, 500)...)
+
+	for _, test := range []struct {
+		name     string
+		file     []byte
+		expected time.Duration
+	}{
+		{
+			name: "constant bit rate",
+			// 100 frames after the ID3 tag, 41700 bytes of audio
+			file:     bytes.Join([][]byte{id3, bytes.Repeat(frame(), 100)}, nil),
+			expected: 2606250 * time.Microsecond,
+		},
+		{
+			name:     "Xing header",
+			file:     bytes.Join([][]byte{id3, bytes.Repeat(frame(xing), 2)}, nil),
+			expected: 1000 * 1152 * time.Second / 44100,
+		},
+		{
+			name:     "VBRI header",
+			file:     bytes.Repeat(frame(vbri), 2),
+			expected: 500 * 1152 * time.Second / 44100,
+		},
+	} {
+		duration, err := mp3Duration(bytes.NewReader(test.file), int64(len(test.file)))
+		if err != nil {
+			t.Errorf("%s: could not read duration: %s", test.name, err)
+			continue
+		}
+		if (duration - test.expected).Abs() > time.Millisecond {
+			t.Errorf("%s: expected a duration of %s, got %s", test.name, test.expected, duration)
+		}
+	}
+}
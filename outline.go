@@ -0,0 +1,136 @@
+package languageserver
+
+import (
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// blockSymbolKinds maps kinds of body blocks to the kind of their symbol in the outline.
+var blockSymbolKinds = map[bodyBlockKind]protocol.SymbolKind{
+	blockTitle:        protocol.SymbolKindClass,
+	blockParagraph:    protocol.SymbolKindString,
+	blockMedia:        protocol.SymbolKindFile,
+	blockLink:         protocol.SymbolKindInterface,
+	blockFootnote:     protocol.SymbolKindKey,
+	blockAbbreviation: protocol.SymbolKindConstant,
+}
+
+// DocumentSymbols returns the outline of the description file: the frontmatter keys, then the blocks of each language section.
+func (d DescriptionFile) DocumentSymbols() []protocol.DocumentSymbol {
+	symbols := make([]protocol.DocumentSymbol, 0)
+	if d.frontmatterEndsAt.Line > 0 {
+		symbols = append(symbols, protocol.DocumentSymbol{
+			Name:           "Frontmatter",
+			Kind:           protocol.SymbolKindNamespace,
+			Range:          d.linesRange(0, int(d.frontmatterEndsAt.Line)),
+			SelectionRange: d.linesRange(0, 0),
+			Children:       frontmatterSymbols(d.frontmatter, d.lines),
+		})
+	}
+
+	sections := d.LanguageSections()
+	for _, section := range sections {
+		children := make([]protocol.DocumentSymbol, 0, len(section.Blocks))
+		for _, block := range section.Blocks {
+			name := block.Name
+			if strings.TrimSpace(name) == "" {
+				name = string(block.Kind)
+			}
+			children = append(children, protocol.DocumentSymbol{
+				Name:           name,
+				Detail:         block.Detail,
+				Kind:           blockSymbolKinds[block.Kind],
+				Range:          d.linesRange(block.Start, block.End),
+				SelectionRange: d.linesRange(block.Start, block.Start),
+			})
+		}
+
+		if section.Marker == -1 {
+			if len(sections) == 1 {
+				// no language markers: the body is not split into languages
+				return append(symbols, children...)
+			}
+			symbols = append(symbols, protocol.DocumentSymbol{
+				Name:           "All languages",
+				Kind:           protocol.SymbolKindModule,
+				Range:          d.linesRange(section.Start, section.End),
+				SelectionRange: d.linesRange(section.Start, section.Start),
+				Children:       children,
+			})
+			continue
+		}
+
+		symbols = append(symbols, protocol.DocumentSymbol{
+			Name:           section.Language,
+			Detail:         titleOf(section),
+			Kind:           protocol.SymbolKindModule,
+			Range:          d.linesRange(section.Start, section.End),
+			SelectionRange: d.linesRange(section.Marker, section.Marker),
+			Children:       children,
+		})
+	}
+	return symbols
+}
+
+// titleOf returns the title of a language section, if it has one.
+func titleOf(section languageSection) string {
+	for _, block := range section.Blocks {
+		if block.Kind == blockTitle {
+			return block.Name
+		}
+	}
+	return ""
+}
+
+// frontmatterSymbols returns a symbol for every key of mapping, with symbols for the keys of nested mappings as children.
+func frontmatterSymbols(mapping *yaml.Node, lines []string) []protocol.DocumentSymbol {
+	symbols := make([]protocol.DocumentSymbol, 0)
+	if mapping.Kind != yaml.MappingNode {
+		return symbols
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if key.Value == "" {
+			continue
+		}
+
+		symbol := protocol.DocumentSymbol{
+			Name: key.Value,
+			Kind: protocol.SymbolKindProperty,
+			Range: protocol.Range{
+				Start: positionOf(key),
+				End:   endOfLine(lines, lastLineOf(value)),
+			},
+			SelectionRange: protocol.Range{Start: positionOf(key), End: endPositionOf(key)},
+		}
+		switch value.Kind {
+		case yaml.ScalarNode:
+			symbol.Detail = value.Value
+		case yaml.SequenceNode:
+			symbol.Kind = protocol.SymbolKindArray
+			items := make([]string, 0, len(value.Content))
+			for _, item := range value.Content {
+				if item.Kind == yaml.ScalarNode {
+					items = append(items, item.Value)
+				}
+			}
+			symbol.Detail = strings.Join(items, ", ")
+		case yaml.MappingNode:
+			symbol.Kind = protocol.SymbolKindObject
+			symbol.Children = frontmatterSymbols(value, lines)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// linesRange returns the range spanning from the start of line start to the end of line end.
+func (d DescriptionFile) linesRange(start int, end int) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(start)},
+		End:   endOfLine(d.lines, uint32(end)),
+	}
+}
@@ -0,0 +1,252 @@
+package languageserver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"go.lsp.dev/protocol"
+)
+
+// portfolio is an ortfodb configuration opened in the workspace, with the state loaded from it and the index of its projects.
+// It is never modified once loaded, except for its index: reloading replaces it with a new one.
+type portfolio struct {
+	configPath string
+	state      state
+	index      *ProjectIndex
+	// err is what prevented the portfolio from being loaded the last time it was. Its state is then degraded.
+	err *loadError
+}
+
+// stateFiles returns the paths of the files the state of the portfolio is loaded from: the ortfodb configuration file and the repositories, and the file that could not be loaded if any.
+func (p *portfolio) stateFiles() []string {
+	files := []string{p.configPath, p.state.config.Tags.Repository, p.state.config.Technologies.Repository}
+	if p.err != nil {
+		files = append(files, p.err.path)
+	}
+	return files
+}
+
+// ownership returns how closely the portfolio owns the file at path, or 0 if it does not own it.
+// The files the state is loaded from are owned the most closely, then files are owned by the portfolio with the deepest projects directory or configuration directory they are in.
+func (p *portfolio) ownership(path string) int {
+	for _, stateFile := range p.stateFiles() {
+		if stateFile != "" && samePath(stateFile, path) {
+			return math.MaxInt
+		}
+	}
+
+	// both are absolute: configPath is made absolute when loading, and the projects directory is resolved against it
+	ownership := 0
+	for _, directory := range []string{p.state.config.ProjectsDirectory, filepath.Dir(p.configPath)} {
+		if directory != "" && isInside(directory, path) {
+			ownership = max(ownership, len(directory))
+		}
+	}
+	return ownership
+}
+
+// isInside returns whether path is directory or one of its descendants.
+func isInside(directory string, path string) bool {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	relative, err := filepath.Rel(directory, absolute)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// Load loads the ortfodb configuration at configPath along with its repositories.
+// If a portfolio was already loaded from configPath, it is replaced, and its project index is discarded since the projects directory might have changed.
+// If loading fails, the portfolio is kept in a degraded state until it is loaded successfully: its previous state if it had one, or what could be loaded otherwise.
+// The error is then a *loadError, locating the file that caused it.
+func (w *Workspace) Load(configPath string) error {
+	if absolute, err := filepath.Abs(configPath); err == nil {
+		configPath = absolute
+	}
+
+	loaded, err := loadState(configPath)
+	var failure *loadError
+	if err != nil && !errors.As(err, &failure) {
+		failure = newLoadError(configPath, err)
+	}
+
+	loadedPortfolio := &portfolio{configPath: configPath, state: loaded, index: &ProjectIndex{}, err: failure}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, existing := range w.portfolios {
+		if !samePath(existing.configPath, configPath) {
+			continue
+		}
+
+		if failure != nil && existing.err == nil {
+			// keep what was loaded before, until the files are fixed
+			previous := existing.state
+			previous.degraded = true
+			loadedPortfolio = &portfolio{configPath: configPath, state: previous, index: existing.index, err: failure}
+		}
+		w.portfolios[i] = loadedPortfolio
+		return err
+	}
+	w.portfolios = append(w.portfolios, loadedPortfolio)
+	return err
+}
+
+// Reload re-reads the configuration at configPath and its repositories, if a portfolio was loaded from it.
+// If loading fails, the previous state of the portfolio is kept.
+func (w *Workspace) Reload(configPath string) error {
+	if !w.Loaded(configPath) {
+		return fmt.Errorf("no portfolio was loaded from %s", configPath)
+	}
+	return w.Load(configPath)
+}
+
+// Unload forgets the portfolio loaded from configPath.
+func (w *Workspace) Unload(configPath string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, existing := range w.portfolios {
+		if samePath(existing.configPath, configPath) {
+			w.portfolios = append(w.portfolios[:i:i], w.portfolios[i+1:]...)
+			return
+		}
+	}
+}
+
+// Loaded returns whether a portfolio was loaded from the configuration at configPath.
+func (w *Workspace) Loaded(configPath string) bool {
+	for _, loaded := range w.ConfigPaths() {
+		if samePath(loaded, configPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigPaths returns the paths of the ortfodb configurations of every portfolio, in the order they were loaded in.
+func (w *Workspace) ConfigPaths() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	paths := make([]string, 0, len(w.portfolios))
+	for _, loaded := range w.portfolios {
+		paths = append(paths, loaded.configPath)
+	}
+	return paths
+}
+
+// portfolioOf returns the portfolio the file at path belongs to, or the first portfolio loaded if it belongs to none of them.
+// ok is false if no portfolio is loaded.
+func (w *Workspace) portfolioOf(path string) (owner *portfolio, ok bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if len(w.portfolios) == 0 {
+		return nil, false
+	}
+
+	owner, closest := w.portfolios[0], 0
+	for _, candidate := range w.portfolios {
+		if ownership := candidate.ownership(path); ownership > closest {
+			owner, closest = candidate, ownership
+		}
+	}
+	return owner, true
+}
+
+// StateOf returns the state of the portfolio the file at uri belongs to, which is empty and degraded if no portfolio is loaded.
+func (w *Workspace) StateOf(uri protocol.URI) state {
+	owner, ok := w.portfolioOf(uri.Filename())
+	if !ok {
+		return state{degraded: true}
+	}
+	return owner.state
+}
+
+// ConfigPathOf returns the path of the ortfodb configuration of the portfolio the file at uri belongs to.
+func (w *Workspace) ConfigPathOf(uri protocol.URI) (string, bool) {
+	owner, ok := w.portfolioOf(uri.Filename())
+	if !ok {
+		return "", false
+	}
+	return owner.configPath, true
+}
+
+// portfoliosLoadedFrom returns the configuration paths of the portfolios whose state is loaded from the file at uri.
+// Repositories can be shared by several portfolios.
+func (w *Workspace) portfoliosLoadedFrom(uri protocol.URI) []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	configPaths := make([]string, 0)
+	for _, loaded := range w.portfolios {
+		for _, stateFile := range loaded.stateFiles() {
+			if stateFile != "" && samePath(stateFile, uri.Filename()) {
+				configPaths = append(configPaths, loaded.configPath)
+				break
+			}
+		}
+	}
+	return configPaths
+}
+
+// failedPortfoliosLoadedFrom returns the configuration paths of the portfolios that could not be loaded, and whose state is loaded from the file at uri.
+func (w *Workspace) failedPortfoliosLoadedFrom(uri protocol.URI) []string {
+	failed := make([]string, 0)
+	for _, loaded := range w.allPortfolios() {
+		if loaded.err == nil {
+			continue
+		}
+		for _, stateFile := range loaded.stateFiles() {
+			if stateFile != "" && samePath(stateFile, uri.Filename()) {
+				failed = append(failed, loaded.configPath)
+				break
+			}
+		}
+	}
+	return failed
+}
+
+// loadErrors returns what prevented portfolios from being loaded.
+func (w *Workspace) loadErrors() []*loadError {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	failures := make([]*loadError, 0)
+	for _, loaded := range w.portfolios {
+		if loaded.err != nil {
+			failures = append(failures, loaded.err)
+		}
+	}
+	return failures
+}
+
+// allPortfolios returns every loaded portfolio.
+func (w *Workspace) allPortfolios() []*portfolio {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]*portfolio{}, w.portfolios...)
+}
+
+// SetFolderConfiguration remembers that configPath was found for the workspace folder at folder.
+func (w *Workspace) SetFolderConfiguration(folder string, configPath string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.folders[folder] = configPath
+}
+
+// RemoveFolder forgets the workspace folder at folder, and returns the configuration that was found for it if no other workspace folder uses it.
+func (w *Workspace) RemoveFolder(folder string) (unused string, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	configPath, found := w.folders[folder]
+	if !found {
+		return "", false
+	}
+	delete(w.folders, folder)
+
+	for _, other := range w.folders {
+		if samePath(other, configPath) {
+			return "", false
+		}
+	}
+	return configPath, true
+}
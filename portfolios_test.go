@@ -0,0 +1,72 @@
+package languageserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+// writePortfolio writes an ortfodb configuration in directory, with its projects in directory/projects, and returns its path.
+func writePortfolio(t *testing.T, directory string, tagsRepository string) string {
+	t.Helper()
+	technologiesRepository := filepath.Join(directory, "technologies.yaml")
+	for _, path := range []string{tagsRepository, technologiesRepository} {
+		if err := os.WriteFile(path, []byte("[]\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(directory, "projects"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(directory, ConfigurationFilename)
+	config := fmt.Sprintf("projects at: %s\nscattered mode folder: .ortfo\ntags:\n  repository: %s\ntechnologies:\n  repository: %s\n", filepath.Join(directory, "projects"), tagsRepository, technologiesRepository)
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+func TestPortfolioRouting(t *testing.T) {
+	root := t.TempDir()
+	sharedTags := filepath.Join(root, "tags.yaml")
+	personal := filepath.Join(root, "personal")
+	studio := filepath.Join(personal, "studio")
+	if err := os.MkdirAll(studio, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	personalConfig := writePortfolio(t, personal, sharedTags)
+	studioConfig := writePortfolio(t, studio, sharedTags)
+
+	workspace, err := NewWorkspace(zap.NewNop(), personalConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.Load(studioConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]string{
+		filepath.Join(personal, "projects", "a", "description.md"):         personalConfig,
+		filepath.Join(studio, "projects", "b", ".ortfo", "description.md"): studioConfig,
+		filepath.Join(studio, "technologies.yaml"):                         studioConfig,
+		filepath.Join(root, "elsewhere", "description.md"):                 personalConfig,
+	} {
+		if configPath, _ := workspace.ConfigPathOf(uri.File(path)); configPath != expected {
+			t.Errorf("%s: expected to belong to %s, got %s", path, expected, configPath)
+		}
+	}
+
+	if loadedFrom := workspace.portfoliosLoadedFrom(uri.File(sharedTags)); len(loadedFrom) != 2 {
+		t.Errorf("expected both portfolios to be loaded from the shared tags repository, got %v", loadedFrom)
+	}
+
+	workspace.Unload(studioConfig)
+	if configPath, _ := workspace.ConfigPathOf(uri.File(filepath.Join(studio, "projects", "b", "description.md"))); configPath != personalConfig {
+		t.Errorf("expected files of an unloaded portfolio to belong to %s, got %s", personalConfig, configPath)
+	}
+}
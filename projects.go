@@ -0,0 +1,36 @@
+package languageserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ortfodb "github.com/ortfo/db"
+)
+
+// ProjectDescriptionFiles returns the paths to the description files of every project in the configured projects directory.
+// Both scattered mode (description files inside each project's scattered mode folder) and regular mode are supported.
+func ProjectDescriptionFiles(config ortfodb.Configuration) ([]string, error) {
+	entries, err := os.ReadDir(config.ProjectsDirectory)
+	if err != nil {
+		return []string{}, fmt.Errorf("while listing projects in %s: %w", config.ProjectsDirectory, err)
+	}
+
+	descriptionFiles := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		for _, candidate := range []string{
+			filepath.Join(config.ProjectsDirectory, entry.Name(), config.ScatteredModeFolder, "description.md"),
+			filepath.Join(config.ProjectsDirectory, entry.Name(), "description.md"),
+		} {
+			if _, err := os.Stat(candidate); err == nil {
+				descriptionFiles = append(descriptionFiles, candidate)
+				break
+			}
+		}
+	}
+	return descriptionFiles, nil
+}
@@ -0,0 +1,48 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	ortfodb "github.com/ortfo/db"
+)
+
+func TestProjectDescriptionFiles(t *testing.T) {
+	projects := t.TempDir()
+	config := ortfodb.Configuration{ProjectsDirectory: projects, ScatteredModeFolder: ".ortfo"}
+	for _, path := range []string{
+		filepath.Join("scattered", ".ortfo", "description.md"),
+		filepath.Join("regular", "description.md"),
+		// scattered mode takes precedence
+		filepath.Join("both", ".ortfo", "description.md"),
+		filepath.Join("both", "description.md"),
+		filepath.Join("empty", "README.md"),
+		"description.md",
+	} {
+		if err := os.MkdirAll(filepath.Join(projects, filepath.Dir(path)), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(projects, path), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := ProjectDescriptionFiles(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(projects, "both", ".ortfo", "description.md"),
+		filepath.Join(projects, "regular", "description.md"),
+		filepath.Join(projects, "scattered", ".ortfo", "description.md"),
+	}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+
+	if _, err := ProjectDescriptionFiles(ortfodb.Configuration{ProjectsDirectory: filepath.Join(projects, "missing")}); err == nil {
+		t.Errorf("expected an error for a missing projects directory")
+	}
+}
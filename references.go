@@ -0,0 +1,310 @@
+package languageserver
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// referenceTarget is an entry of the tags or technologies repository, that description files can refer to.
+type referenceTarget struct {
+	// kind is either "tag" or "technology"
+	kind string
+	// frontmatterKey is the frontmatter key under which entries of this kind are referred to
+	frontmatterKey string
+	// repository is the path to the repository file the entry is defined in
+	repository string
+	item       referrable
+	node       *yaml.Node
+	// name is the name the entry was referred to by where the cursor is, and nameLocation where that name is written.
+	name         string
+	nameLocation protocol.Location
+}
+
+// Declaration returns the location of the name of the entry in the repository file.
+func (t referenceTarget) Declaration() protocol.Location {
+	nameNode := t.node
+	if field, ok := fieldOf(t.node, primaryNameField(t.kind)); ok {
+		nameNode = field
+	}
+	return locationOf(uri.File(t.repository), nameNode)
+}
+
+// referenceTargetAt returns the repository entry under the cursor, which is either on a frontmatter tag or technology of a description file, or on an entry of one of the repository files.
+func (h Handler) referenceTargetAt(at protocol.URI, position protocol.Position) (referenceTarget, bool, error) {
+	current := h.stateOf(at)
+	for _, kind := range []string{"tag", "technology"} {
+		repository := repositoryPath(current.config, kind)
+		if !samePath(repository, at.Filename()) {
+			continue
+		}
+
+		contents, err := h.Workspace.Contents(at)
+		if err != nil {
+			return referenceTarget{}, false, fmt.Errorf("while reading %s repository: %w", kind, err)
+		}
+
+		repo, err := ParseRepository([]byte(contents))
+		if err != nil {
+			return referenceTarget{}, false, fmt.Errorf("while parsing %s repository: %w", kind, err)
+		}
+
+		node, ok := EntryAt(repo, position)
+		if !ok {
+			return referenceTarget{}, false, nil
+		}
+
+		item, err := decodeReferrable(kind, node)
+		if err != nil {
+			return referenceTarget{}, false, err
+		}
+
+		nameNode, ok := nameNodeAt(node, position)
+		if !ok {
+			nameNode, ok = fieldOf(node, primaryNameField(kind))
+		}
+		if !ok {
+			return referenceTarget{}, false, fmt.Errorf("%s has no %s", kind, primaryNameField(kind))
+		}
+
+		return referenceTarget{
+			kind:           kind,
+			frontmatterKey: frontmatterKeyOf(kind),
+			repository:     repository,
+			item:           item,
+			node:           node,
+			name:           nameNode.Value,
+			nameLocation:   locationOf(at, nameNode),
+		}, true, nil
+	}
+
+	if filepath.Base(at.Filename()) != "description.md" {
+		return referenceTarget{}, false, nil
+	}
+
+	file, err := h.Workspace.CurrentFile(at, position)
+	if err != nil {
+		return referenceTarget{}, false, fmt.Errorf("while getting current file: %w", err)
+	}
+
+	key, node, inside := file.InFrontmatter()
+	if !inside || node == nil {
+		return referenceTarget{}, false, nil
+	}
+
+	var (
+		entry *yaml.Node
+		item  referrable
+	)
+	switch key {
+	case "tags":
+		var tag *ortfodb.Tag
+		entry, tag, err = FindInRepository[ortfodb.Tag](node.Value, "tag", current.tags)
+		if tag != nil {
+			item = *tag
+		}
+	case "made with":
+		var technology *ortfodb.Technology
+		entry, technology, err = FindInRepository[ortfodb.Technology](node.Value, "technology", current.technologies)
+		if technology != nil {
+			item = *technology
+		}
+	default:
+		return referenceTarget{}, false, nil
+	}
+	if errors.Is(err, errNotInRepository) {
+		// unknown tags and technologies are reported by diagnostics already
+		return referenceTarget{}, false, nil
+	}
+	if err != nil {
+		return referenceTarget{}, false, err
+	}
+
+	kind := kindOf(key)
+	return referenceTarget{
+		kind:           kind,
+		frontmatterKey: key,
+		repository:     repositoryPath(current.config, kind),
+		item:           item,
+		node:           entry,
+		name:           node.Value,
+		nameLocation:   locationOf(at, node),
+	}, true, nil
+}
+
+// ReferencesTo returns the location of every frontmatter entry that refers to target, in every project of the portfolios that use the repository target is defined in.
+func (h Handler) ReferencesTo(target referenceTarget) ([]protocol.Location, error) {
+	return h.referencesMatching(target, target.item.ReferredToBy)
+}
+
+// referencesByName returns the location of every frontmatter entry that refers to target by the name it was referred to by where the cursor is, case-insensitively.
+// References using its other names or aliases are left out.
+func (h Handler) referencesByName(target referenceTarget) ([]protocol.Location, error) {
+	return h.referencesMatching(target, func(name string) bool {
+		return strings.EqualFold(name, target.name)
+	})
+}
+
+// referencesMatching returns the location of every frontmatter entry under the key of target's kind whose value matches, in every project of the portfolios that use the repository target is defined in.
+// For technologies, the entries of the "detect: made with" lists of their tags repositories that match are included as well.
+func (h Handler) referencesMatching(target referenceTarget, matches func(string) bool) ([]protocol.Location, error) {
+	descriptionFiles := make([]string, 0)
+	tagsRepositories := make([]string, 0)
+	for _, loaded := range h.Workspace.allPortfolios() {
+		if !samePath(repositoryPath(loaded.state.config, target.kind), target.repository) {
+			continue
+		}
+
+		paths, err := ProjectDescriptionFiles(loaded.state.config)
+		if err != nil {
+			return []protocol.Location{}, err
+		}
+		descriptionFiles = append(descriptionFiles, paths...)
+
+		// portfolios can share their tags repository, whose references must only be found once
+		tags := loaded.state.config.Tags.Repository
+		if target.kind == "technology" && !slices.ContainsFunc(tagsRepositories, func(path string) bool { return samePath(path, tags) }) {
+			tagsRepositories = append(tagsRepositories, tags)
+		}
+	}
+
+	locations := make([]protocol.Location, 0)
+	for _, path := range descriptionFiles {
+		contents, err := h.Workspace.Contents(uri.File(path))
+		if err != nil {
+			h.Logger.Debug("ReferencesTo:skipping unreadable description file", zap.String("path", path), zap.Error(err))
+			continue
+		}
+
+		for _, node := range ParseDescriptionFile(contents, protocol.Position{}).referencesMatching(target.frontmatterKey, matches) {
+			locations = append(locations, locationOf(uri.File(path), node))
+		}
+	}
+
+	for _, path := range tagsRepositories {
+		contents, err := h.Workspace.Contents(uri.File(path))
+		if err != nil {
+			h.Logger.Debug("ReferencesTo:skipping unreadable tags repository", zap.String("path", path), zap.Error(err))
+			continue
+		}
+
+		tags, err := ParseRepository([]byte(contents))
+		if err != nil {
+			h.Logger.Debug("ReferencesTo:skipping invalid tags repository", zap.String("path", path), zap.Error(err))
+			continue
+		}
+
+		for _, node := range detectionsMatching(tags, matches) {
+			locations = append(locations, locationOf(uri.File(path), node))
+		}
+	}
+	return locations, nil
+}
+
+// detectionsMatching returns the entries of the "detect: made with" lists of the tags whose value matches.
+func detectionsMatching(tags []yaml.Node, matches func(string) bool) []*yaml.Node {
+	nodes := make([]*yaml.Node, 0)
+	for i := range tags {
+		detect, ok := fieldOf(&tags[i], "detect")
+		if !ok {
+			continue
+		}
+		technologies, ok := fieldOf(detect, "made with")
+		if !ok || technologies.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, item := range technologies.Content {
+			if item.Kind == yaml.ScalarNode && matches(item.Value) {
+				nodes = append(nodes, item)
+			}
+		}
+	}
+	return nodes
+}
+
+// ReferencesTo returns the frontmatter nodes of this description file that refer to target.
+func (d DescriptionFile) ReferencesTo(target referenceTarget) []*yaml.Node {
+	return d.referencesMatching(target.frontmatterKey, target.item.ReferredToBy)
+}
+
+// referencesMatching returns the entries of the frontmatter sequence at key whose value matches.
+func (d DescriptionFile) referencesMatching(key string, matches func(string) bool) []*yaml.Node {
+	nodes := make([]*yaml.Node, 0)
+	sequence, ok := d.frontmatterMappings[key]
+	if !ok || sequence.Kind != yaml.SequenceNode {
+		return nodes
+	}
+
+	for _, item := range sequence.Content {
+		if item.Kind == yaml.ScalarNode && matches(item.Value) {
+			nodes = append(nodes, item)
+		}
+	}
+	return nodes
+}
+
+func locationOf(at protocol.URI, node *yaml.Node) protocol.Location {
+	return protocol.Location{
+		URI: at,
+		Range: protocol.Range{
+			Start: positionOf(node),
+			End:   endPositionOf(node),
+		},
+	}
+}
+
+func decodeReferrable(kind string, node *yaml.Node) (referrable, error) {
+	switch kind {
+	case "tag":
+		var tag ortfodb.Tag
+		if err := node.Decode(&tag); err != nil {
+			return nil, fmt.Errorf("while decoding tag: %w", err)
+		}
+		return tag, nil
+	case "technology":
+		var technology ortfodb.Technology
+		if err := node.Decode(&technology); err != nil {
+			return nil, fmt.Errorf("while decoding technology: %w", err)
+		}
+		return technology, nil
+	}
+	return nil, fmt.Errorf("unknown repository kind %q", kind)
+}
+
+func repositoryPath(config ortfodb.Configuration, kind string) string {
+	if kind == "tag" {
+		return config.Tags.Repository
+	}
+	return config.Technologies.Repository
+}
+
+func frontmatterKeyOf(kind string) string {
+	if kind == "tag" {
+		return "tags"
+	}
+	return "made with"
+}
+
+func kindOf(frontmatterKey string) string {
+	if frontmatterKey == "tags" {
+		return "tag"
+	}
+	return "technology"
+}
+
+// primaryNameField returns the field of repository entries that holds the name they are primarily referred to by.
+func primaryNameField(kind string) string {
+	if kind == "tag" {
+		return "singular"
+	}
+	return "slug"
+}
@@ -0,0 +1,91 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+func TestDescriptionFileReferencesTo(t *testing.T) {
+	technologies, err := ParseRepository([]byte("- slug: javascript\n  name: JavaScript\n  aliases: [js]\n- slug: go\n  name: Go\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := decodeReferrable("technology", &technologies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := referenceTarget{kind: "technology", frontmatterKey: "made with", item: item, node: &technologies[0], name: "javascript"}
+
+	file := ParseDescriptionFile("---\ntags: [javascript]\nmade with:\n  - js\n  - go\n  - JavaScript\n---\n\nWritten in javascript.\n", protocol.Position{})
+	references := file.ReferencesTo(target)
+	expected := []protocol.Position{{Line: 3, Character: 4}, {Line: 5, Character: 4}}
+	if len(references) != len(expected) {
+		t.Fatalf("expected %d references, got %d: %+v", len(expected), len(references), references)
+	}
+	for i, position := range expected {
+		if positionOf(references[i]) != position {
+			t.Errorf("reference %d: expected at %v, got %v", i, position, positionOf(references[i]))
+		}
+	}
+}
+
+func TestReferenceTargetAtUnknownName(t *testing.T) {
+	root := t.TempDir()
+	configPath := writePortfolio(t, root, filepath.Join(root, "tags.yaml"))
+	descriptionFile := filepath.Join(root, "projects", "app", "description.md")
+	if err := os.MkdirAll(filepath.Dir(descriptionFile), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(descriptionFile, []byte("---\ntags: [typo]\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Handler{Workspace: workspace, Logger: zap.NewNop()}
+	if _, found, err := h.referenceTargetAt(uri.File(descriptionFile), protocol.Position{Line: 1, Character: 8}); found || err != nil {
+		t.Errorf("expected no target and no error on an unknown tag, got found=%v, err=%v", found, err)
+	}
+}
+
+func TestReferencesToInTagsRepository(t *testing.T) {
+	root := t.TempDir()
+	tagsRepository := filepath.Join(root, "tags.yaml")
+	configPath := writePortfolio(t, root, tagsRepository)
+	technologiesRepository := filepath.Join(root, "technologies.yaml")
+	for path, contents := range map[string]string{
+		tagsRepository:         "- singular: web\n  plural: webs\n  detect:\n    made with: [go, js]\n- singular: app\n  plural: apps\n  detect:\n    files: [js]\n",
+		technologiesRepository: "- slug: javascript\n  name: JavaScript\n  aliases: [js]\n",
+	} {
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Handler{Workspace: workspace, Logger: zap.NewNop()}
+	target, found, err := h.referenceTargetAt(uri.File(technologiesRepository), protocol.Position{Line: 0, Character: 10})
+	if err != nil || !found {
+		t.Fatalf("expected a technology, got %v", err)
+	}
+
+	references, err := h.ReferencesTo(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only made with lists are references to technologies
+	expected := protocol.Location{URI: uri.File(tagsRepository), Range: span(3, 20, 3, 22)}
+	if len(references) != 1 || references[0] != expected {
+		t.Errorf("expected a reference at %v, got %+v", expected, references)
+	}
+}
@@ -1,10 +1,13 @@
 package languageserver
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
+	ortfodb "github.com/ortfo/db"
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -16,6 +19,28 @@ type referrable interface {
 	URLFriendlyName() string
 }
 
+// namesOf returns every name item can be referred to by.
+func namesOf(item referrable) []string {
+	switch item := item.(type) {
+	case ortfodb.Tag:
+		return append([]string{item.Singular, item.Plural}, item.Aliases...)
+	case ortfodb.Technology:
+		return append([]string{item.Slug, item.Name}, item.Aliases...)
+	}
+	return []string{item.DisplayName(), item.URLFriendlyName()}
+}
+
+// descriptionOf returns the description of item, as written in its repository.
+func descriptionOf(item referrable) string {
+	switch item := item.(type) {
+	case ortfodb.Tag:
+		return item.Description
+	case ortfodb.Technology:
+		return item.Description
+	}
+	return ""
+}
+
 func ReferrableDescription(item referrable, description string) protocol.MarkupContent {
 	return protocol.MarkupContent{
 		Kind: protocol.Markdown,
@@ -25,6 +50,9 @@ func ReferrableDescription(item referrable, description string) protocol.MarkupC
 	}
 }
 
+// errNotInRepository is returned by FindInRepository when no entry is referred to by the name.
+var errNotInRepository = errors.New("not found in repository")
+
 func FindInRepository[T referrable](name string, kind string, repo []yaml.Node) (*yaml.Node, *T, error) {
 	logger.Debug("InDefintionLocationOf", zap.String("name", name), zap.Any("repo", repo))
 	for _, tagNode := range repo {
@@ -40,7 +68,7 @@ func FindInRepository[T referrable](name string, kind string, repo []yaml.Node)
 		}
 	}
 
-	return nil, nil, fmt.Errorf("%s %q not found in repository", kind, name)
+	return nil, nil, fmt.Errorf("%s %q %w", kind, name, errNotInRepository)
 }
 
 func LoadRepository(at string) ([]yaml.Node, error) {
@@ -49,11 +77,107 @@ func LoadRepository(at string) ([]yaml.Node, error) {
 		return []yaml.Node{}, fmt.Errorf("while reading file %s: %w", at, err)
 	}
 
-	var tags []yaml.Node
-	err = yaml.Unmarshal(contents, &tags)
+	tags, err := ParseRepository(contents)
 	if err != nil {
 		return []yaml.Node{}, fmt.Errorf("while parsing %s as YAML: %w", at, err)
 	}
 
 	return tags, nil
 }
+
+// ParseRepository parses the contents of a tags or technologies repository file.
+func ParseRepository(contents []byte) ([]yaml.Node, error) {
+	var entries []yaml.Node
+	err := yaml.Unmarshal(contents, &entries)
+	if err != nil {
+		return []yaml.Node{}, err
+	}
+
+	return entries, nil
+}
+
+// EntryAt returns the entry of repo that spans the line of the given position.
+func EntryAt(repo []yaml.Node, position protocol.Position) (*yaml.Node, bool) {
+	for i := range repo {
+		if positionOf(&repo[i]).Line > position.Line {
+			break
+		}
+
+		if i+1 == len(repo) || positionOf(&repo[i+1]).Line > position.Line {
+			return &repo[i], true
+		}
+	}
+	return nil, false
+}
+
+// ClosestInRepository returns the name, among all names of all entries of repo, that is the closest to name.
+// ok is false if no name is close enough to be a plausible typo.
+func ClosestInRepository[T referrable](name string, repo []yaml.Node) (closest string, ok bool) {
+	bestDistance := max(1, len(name)/3) + 1
+	for _, node := range repo {
+		var item T
+		if err := node.Decode(&item); err != nil {
+			continue
+		}
+
+		for _, candidate := range namesOf(item) {
+			distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+			if distance < bestDistance {
+				bestDistance = distance
+				closest = candidate
+			}
+		}
+	}
+	return closest, closest != ""
+}
+
+// fieldOf returns the value node of the given field of a repository entry.
+func fieldOf(entry *yaml.Node, field string) (*yaml.Node, bool) {
+	if entry.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	for i := 0; i+1 < len(entry.Content); i += 2 {
+		if entry.Content[i].Value == field {
+			return entry.Content[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// nameFields lists the fields of repository entries that hold a name the entry can be referred to by, except aliases.
+var nameFields = []string{"singular", "plural", "slug", "name"}
+
+// nameNodeAt returns the node of the name (or alias) of a repository entry that is under the cursor.
+func nameNodeAt(entry *yaml.Node, position protocol.Position) (*yaml.Node, bool) {
+	for _, node := range nameNodesOf(entry) {
+		if positionOf(node).Line == position.Line && !isAfter(positionOf(node), position) && !isAfter(position, endPositionOf(node)) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// nameNodeReferredToBy returns the node of the name (or alias) of a repository entry that is loosely equal to name.
+func nameNodeReferredToBy(entry *yaml.Node, name string) (*yaml.Node, bool) {
+	for _, node := range nameNodesOf(entry) {
+		if strings.EqualFold(node.Value, name) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// nameNodesOf returns the nodes of every name and alias of a repository entry.
+func nameNodesOf(entry *yaml.Node) []*yaml.Node {
+	nodes := make([]*yaml.Node, 0)
+	for _, field := range nameFields {
+		if node, ok := fieldOf(entry, field); ok && node.Kind == yaml.ScalarNode {
+			nodes = append(nodes, node)
+		}
+	}
+	if aliases, ok := fieldOf(entry, "aliases"); ok && aliases.Kind == yaml.SequenceNode {
+		nodes = append(nodes, aliases.Content...)
+	}
+	return nodes
+}
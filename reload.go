@@ -0,0 +1,38 @@
+package languageserver
+
+import (
+	"context"
+	"path/filepath"
+
+	"go.lsp.dev/uri"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// Reload re-reads the ortfodb configuration and the repositories of the portfolios loaded from configPaths, replaces their state with them and re-publishes diagnostics for every open file.
+// If loading a portfolio fails, its previous state is kept in a degraded state and the error is reported to the user.
+// Diagnostics of the files that prevented portfolios from being loaded before are published again, to clear them once they are fixed.
+func (h Handler) Reload(ctx context.Context, configPaths ...string) error {
+	previousLoadErrors := h.Workspace.loadErrors()
+
+	var loadErrs error
+	for _, configPath := range configPaths {
+		h.Logger.Info("Reloading state", zap.String("configpath", configPath))
+		loadErrs = multierr.Append(loadErrs, h.Workspace.Reload(configPath))
+	}
+
+	errs := h.reportLoadErrors(ctx, loadErrs)
+	for _, previous := range previousLoadErrors {
+		errs = multierr.Append(errs, h.PublishDiagnostics(ctx, uri.File(previous.path)))
+	}
+	return multierr.Append(errs, h.republishDiagnostics(ctx))
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
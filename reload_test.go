@@ -0,0 +1,91 @@
+package languageserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+// recordingClient records the diagnostics and messages sent to the client. Other requests panic.
+type recordingClient struct {
+	protocol.Client
+	published map[protocol.URI][]protocol.Diagnostic
+	messages  []string
+}
+
+func (c *recordingClient) PublishDiagnostics(ctx context.Context, params *protocol.PublishDiagnosticsParams) error {
+	c.published[params.URI] = params.Diagnostics
+	return nil
+}
+
+func (c *recordingClient) ShowMessage(ctx context.Context, params *protocol.ShowMessageParams) error {
+	c.messages = append(c.messages, params.Message)
+	return nil
+}
+
+func TestReload(t *testing.T) {
+	root := t.TempDir()
+	tagsRepository := filepath.Join(root, "tags.yaml")
+	configPath := writePortfolio(t, root, tagsRepository)
+	if err := os.WriteFile(tagsRepository, []byte("- singular: site\n  plural: sites\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &recordingClient{published: make(map[protocol.URI][]protocol.Diagnostic)}
+	h := Handler{Workspace: workspace, Logger: zap.NewNop(), Client: client}
+
+	description := uri.File(filepath.Join(root, "projects", "app", "description.md"))
+	workspace.Open(description, 1, "---\ntags: [web]\n---\n")
+	codesOf := func(diagnostics []protocol.Diagnostic) []string {
+		codes := make([]string, 0, len(diagnostics))
+		for _, diagnostic := range diagnostics {
+			codes = append(codes, fmt.Sprint(diagnostic.Code))
+		}
+		return codes
+	}
+
+	// the new repository replaces the old one
+	if err := os.WriteFile(tagsRepository, []byte("- singular: site\n  plural: sites\n- singular: web\n  plural: webs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Reload(context.Background(), configPath); err != nil {
+		t.Fatal(err)
+	}
+	if current := workspace.StateOf(description); current.degraded || len(current.tags) != 2 {
+		t.Errorf("expected the reloaded state with 2 tags, got %d tags (degraded: %v)", len(current.tags), current.degraded)
+	}
+	diagnostics, published := client.published[description]
+	if !published || len(diagnostics) != 0 {
+		t.Errorf("expected diagnostics of the open description file to be published again without problems, got %v (published: %v)", codesOf(diagnostics), published)
+	}
+
+	// a failed reload keeps the previous state
+	delete(client.published, description)
+	if err := os.WriteFile(tagsRepository, []byte("- singular: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Reload(context.Background(), configPath); err != nil {
+		t.Fatal(err)
+	}
+	if current := workspace.StateOf(description); !current.degraded || len(current.tags) != 2 {
+		t.Errorf("expected the previous state with 2 tags to be kept, degraded, got %d tags (degraded: %v)", len(current.tags), current.degraded)
+	}
+	if len(client.messages) != 1 {
+		t.Errorf("expected the failure to be shown to the user, got messages %v", client.messages)
+	}
+	if _, published := client.published[description]; !published {
+		t.Errorf("expected diagnostics of the open description file to be published again")
+	}
+	if diagnostics := client.published[uri.File(tagsRepository)]; len(diagnostics) != 1 {
+		t.Errorf("expected the problem in the tags repository to be published, got %v", codesOf(diagnostics))
+	}
+}
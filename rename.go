@@ -0,0 +1,156 @@
+package languageserver
+
+import (
+	"fmt"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"gopkg.in/yaml.v3"
+)
+
+// RenameEdits returns the edits needed to rename target to newName: in the repository entry, and in every frontmatter (and, for technologies, every "detect: made with" list of the tags repository) that refers to it by the name being renamed.
+// If keepOldName is true, the previous name is added to the entry's aliases.
+func (h Handler) RenameEdits(target referenceTarget, newName string, keepOldName bool) (*protocol.WorkspaceEdit, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return nil, fmt.Errorf("the new name of the %s cannot be empty", target.kind)
+	}
+
+	repositoryURI := uri.File(target.repository)
+	contents, err := h.Workspace.Contents(repositoryURI)
+	if err != nil {
+		return nil, fmt.Errorf("while reading %s repository: %w", target.kind, err)
+	}
+
+	repo, err := ParseRepository([]byte(contents))
+	if err != nil {
+		return nil, fmt.Errorf("while parsing %s repository: %w", target.kind, err)
+	}
+
+	var entry *yaml.Node
+	for i := range repo {
+		item, err := decodeReferrable(target.kind, &repo[i])
+		if err != nil {
+			continue
+		}
+
+		if item.ReferredToBy(target.name) {
+			entry = &repo[i]
+		} else if item.ReferredToBy(newName) {
+			return nil, fmt.Errorf("another %s is already referred to by %q", target.kind, newName)
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%s %q not found in repository", target.kind, target.name)
+	}
+
+	renamed, ok := nameNodeReferredToBy(entry, target.name)
+	if !ok {
+		renamed, ok = fieldOf(entry, primaryNameField(target.kind))
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s %q has no %s", target.kind, target.name, primaryNameField(target.kind))
+	}
+
+	changes := map[protocol.DocumentURI][]protocol.TextEdit{
+		repositoryURI: {
+			{Range: locationOf(repositoryURI, renamed).Range, NewText: yamlScalar(newName)},
+		},
+	}
+
+	if keepOldName && !isAlias(entry, renamed) {
+		changes[repositoryURI] = append(changes[repositoryURI], addAliasEdit(entry, renamed.Value, strings.Split(contents, "\n")))
+	}
+
+	// other names and aliases still refer to the entry once renamed
+	references, err := h.referencesByName(target)
+	if err != nil {
+		return nil, fmt.Errorf("while searching references: %w", err)
+	}
+
+	for _, reference := range references {
+		changes[reference.URI] = append(changes[reference.URI], protocol.TextEdit{
+			Range:   reference.Range,
+			NewText: yamlScalar(newName),
+		})
+	}
+
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+func isAlias(entry *yaml.Node, node *yaml.Node) bool {
+	aliases, ok := fieldOf(entry, "aliases")
+	if !ok {
+		return false
+	}
+
+	for _, alias := range aliases.Content {
+		if alias == node {
+			return true
+		}
+	}
+	return false
+}
+
+// addAliasEdit returns an edit that adds alias to the aliases of entry, creating the field if needed.
+// lines are the lines of the repository file.
+func addAliasEdit(entry *yaml.Node, alias string, lines []string) protocol.TextEdit {
+	if aliases, ok := fieldOf(entry, "aliases"); ok && aliases.Kind == yaml.SequenceNode {
+		if len(aliases.Content) == 0 {
+			// only possible in flow style: insert right after the opening bracket
+			inside := positionOf(aliases)
+			inside.Character++
+			return insertion(inside, yamlScalar(alias))
+		}
+
+		last := aliases.Content[len(aliases.Content)-1]
+		if aliases.Style&yaml.FlowStyle != 0 {
+			return insertion(endPositionOf(last), ", "+yamlScalar(alias))
+		}
+
+		lastLine := lines[positionOf(last).Line]
+		indentation := lastLine[:len(lastLine)-len(strings.TrimLeft(lastLine, " \t"))]
+		return insertion(endOfLine(lines, positionOf(last).Line), "\n"+indentation+"- "+yamlScalar(alias))
+	}
+
+	indentation := strings.Repeat(" ", int(positionOf(entry).Character))
+	return insertion(endOfLine(lines, lastLineOf(entry)), "\n"+indentation+"aliases: ["+yamlScalar(alias)+"]")
+}
+
+func insertion(at protocol.Position, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range:   protocol.Range{Start: at, End: at},
+		NewText: text,
+	}
+}
+
+func endOfLine(lines []string, line uint32) protocol.Position {
+	return protocol.Position{
+		Line:      line,
+		Character: uint32(utf16Len(strings.TrimSuffix(lines[line], "\r"))),
+	}
+}
+
+// lastLineOf returns the last line spanned by node, as a 0-based line number.
+func lastLineOf(node *yaml.Node) uint32 {
+	last := positionOf(node).Line
+	for _, child := range node.Content {
+		last = max(last, lastLineOf(child))
+	}
+	return last
+}
+
+// yamlScalar encodes value as a YAML scalar that is valid in both block and flow style.
+func yamlScalar(value string) string {
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	scalar := strings.TrimSuffix(string(encoded), "\n")
+	if strings.ContainsAny(scalar, ",[]{}") && !strings.HasPrefix(scalar, "'") && !strings.HasPrefix(scalar, `"`) {
+		return fmt.Sprintf("%q", value)
+	}
+	return scalar
+}
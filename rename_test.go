@@ -0,0 +1,164 @@
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"go.uber.org/zap"
+)
+
+// applyEdits returns contents with edits applied, as a client would: edits are all relative to the original contents.
+func applyEdits(t *testing.T, contents string, edits []protocol.TextEdit) string {
+	t.Helper()
+	sorted := append([]protocol.TextEdit{}, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Range.Start, sorted[j].Range.Start
+		return a.Line > b.Line || a.Line == b.Line && a.Character > b.Character
+	})
+	for _, edit := range sorted {
+		start, err := byteOffset(contents, edit.Range.Start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end, err := byteOffset(contents, edit.Range.End)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = contents[:start] + edit.NewText + contents[end:]
+	}
+	return contents
+}
+
+func TestYamlScalar(t *testing.T) {
+	for value, expected := range map[string]string{
+		"web":         "web",
+		"web design":  "web design",
+		"C++":         "C++",
+		"a, b":        `"a, b"`,
+		"[brackets]":  `'[brackets]'`,
+		"yes":         `"yes"`,
+		"key: value":  `'key: value'`,
+		"#hashtag":    `'#hashtag'`,
+		"":            `""`,
+		"trailing, ,": `"trailing, ,"`,
+	} {
+		if scalar := yamlScalar(value); scalar != expected {
+			t.Errorf("yamlScalar(%q): expected %s, got %s", value, expected, scalar)
+		}
+	}
+}
+
+func TestAddAliasEdit(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{
+			name:     "block",
+			contents: "- slug: javascript\n  aliases:\n    - js\n  name: JavaScript\n",
+			expected: "- slug: javascript\n  aliases:\n    - js\n    - ecmascript\n  name: JavaScript\n",
+		},
+		{
+			name:     "flow",
+			contents: "- slug: javascript\n  aliases: [js]\n",
+			expected: "- slug: javascript\n  aliases: [js, ecmascript]\n",
+		},
+		{
+			name:     "empty flow",
+			contents: "- slug: javascript\n  aliases: []\n",
+			expected: "- slug: javascript\n  aliases: [ecmascript]\n",
+		},
+		{
+			name:     "missing",
+			contents: "- slug: javascript\n  name: JavaScript\n",
+			expected: "- slug: javascript\n  name: JavaScript\n  aliases: [ecmascript]\n",
+		},
+	} {
+		repo, err := ParseRepository([]byte(test.contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		edit := addAliasEdit(&repo[0], "ecmascript", strings.Split(test.contents, "\n"))
+		if result := applyEdits(t, test.contents, []protocol.TextEdit{edit}); result != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestRenameEdits(t *testing.T) {
+	root := t.TempDir()
+	tagsRepository := filepath.Join(root, "tags.yaml")
+	configPath := writePortfolio(t, root, tagsRepository)
+	tags := "- singular: web\n  plural: webs\n  detect:\n    made with: [js, JavaScript]\n"
+	technologiesRepository := filepath.Join(root, "technologies.yaml")
+	technologies := "- slug: javascript\n  name: JavaScript\n  aliases: [js]\n"
+	descriptionFile := filepath.Join(root, "projects", "app", "description.md")
+	description := "---\nmade with: [js, javascript, JavaScript, JS]\n---\n"
+	for path, contents := range map[string]string{tagsRepository: tags, technologiesRepository: technologies, descriptionFile: description} {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workspace, err := NewWorkspace(zap.NewNop(), configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Handler{Workspace: workspace, Logger: zap.NewNop()}
+
+	for _, test := range []struct {
+		// at is where the cursor is in the technologies repository
+		at                  protocol.Position
+		newName             string
+		keepOldName         bool
+		expectedRepository  string
+		expectedFrontmatter string
+		expectedTags        string
+	}{
+		{
+			at:                  protocol.Position{Line: 2, Character: 13},
+			newName:             "ecmascript",
+			expectedRepository:  "- slug: javascript\n  name: JavaScript\n  aliases: [ecmascript]\n",
+			expectedFrontmatter: "---\nmade with: [ecmascript, javascript, JavaScript, ecmascript]\n---\n",
+			expectedTags:        "- singular: web\n  plural: webs\n  detect:\n    made with: [ecmascript, JavaScript]\n",
+		},
+		{
+			at:                  protocol.Position{Line: 0, Character: 10},
+			newName:             "js-lang",
+			keepOldName:         true,
+			expectedRepository:  "- slug: js-lang\n  name: JavaScript\n  aliases: [js, javascript]\n",
+			expectedFrontmatter: "---\nmade with: [js, js-lang, js-lang, JS]\n---\n",
+			expectedTags:        "- singular: web\n  plural: webs\n  detect:\n    made with: [js, js-lang]\n",
+		},
+	} {
+		target, found, err := h.referenceTargetAt(uri.File(technologiesRepository), test.at)
+		if err != nil || !found {
+			t.Fatalf("expected a technology at %v, got %v", test.at, err)
+		}
+
+		edit, err := h.RenameEdits(target, test.newName, test.keepOldName)
+		if err != nil {
+			t.Errorf("renaming %q to %q: %v", target.name, test.newName, err)
+			continue
+		}
+		if result := applyEdits(t, technologies, edit.Changes[uri.File(technologiesRepository)]); result != test.expectedRepository {
+			t.Errorf("renaming %q to %q: expected repository %q, got %q", target.name, test.newName, test.expectedRepository, result)
+		}
+		if result := applyEdits(t, description, edit.Changes[uri.File(descriptionFile)]); result != test.expectedFrontmatter {
+			t.Errorf("renaming %q to %q: expected frontmatter %q, got %q", target.name, test.newName, test.expectedFrontmatter, result)
+		}
+		if result := applyEdits(t, tags, edit.Changes[uri.File(tagsRepository)]); result != test.expectedTags {
+			t.Errorf("renaming %q to %q: expected tags repository %q, got %q", target.name, test.newName, test.expectedTags, result)
+		}
+	}
+}
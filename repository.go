@@ -0,0 +1,405 @@
+package languageserver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// tagSchema lists every field of entries of the tags repository. See ortfodb.Tag.
+var tagSchema = []schemaKey{
+	{
+		Name:          "singular",
+		Kind:          yaml.ScalarNode,
+		Required:      true,
+		Documentation: "Singular-form name of the tag, e.g. `book`.",
+	},
+	{
+		Name:          "plural",
+		Kind:          yaml.ScalarNode,
+		Required:      true,
+		Documentation: "Plural-form name of the tag, e.g. `books`.",
+	},
+	{
+		Name:          "description",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Description of the tag.",
+	},
+	{
+		Name:          "learn more at",
+		Kind:          yaml.ScalarNode,
+		Documentation: "URL to a website where more information can be found about this tag.",
+	},
+	{
+		Name:          "aliases",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Other names that refer to this tag. They should not be used to define other tags.",
+	},
+	{
+		Name:          "detect",
+		Kind:          yaml.MappingNode,
+		Documentation: "Various ways to automatically detect that a work is tagged with this tag.",
+		Children: []schemaKey{
+			{Name: "files", Kind: yaml.SequenceNode, Documentation: "Tag the work if it contains any of these files, relative to the work's folder. Glob patterns are supported."},
+			{Name: "search", Kind: yaml.SequenceNode, Documentation: "Tag the work if its files contain any of these strings."},
+			{Name: "made with", Kind: yaml.SequenceNode, Documentation: "Tag the work if it was made with any of these technologies."},
+		},
+	},
+}
+
+// technologySchema lists every field of entries of the technologies repository. See ortfodb.Technology.
+var technologySchema = []schemaKey{
+	{
+		Name:          "slug",
+		Kind:          yaml.ScalarNode,
+		Required:      true,
+		Documentation: "Unique identifier of the technology, suitable for use in a website's URL.",
+	},
+	{
+		Name:          "name",
+		Kind:          yaml.ScalarNode,
+		Required:      true,
+		Documentation: "Display name of the technology.",
+	},
+	{
+		Name:          "by",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Name of the person or organization that created this technology.",
+	},
+	{
+		Name:          "description",
+		Kind:          yaml.ScalarNode,
+		Documentation: "Description of the technology.",
+	},
+	{
+		Name:          "learn more at",
+		Kind:          yaml.ScalarNode,
+		Documentation: "URL to a website where more information can be found about this technology.",
+	},
+	{
+		Name:          "aliases",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Other slugs that refer to this technology. They should not be used to define other technologies.",
+	},
+	{
+		Name:          "files",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Gitignore-style patterns. The technology is considered used by a work that contains any of these files.",
+	},
+	{
+		Name:          "autodetect",
+		Kind:          yaml.SequenceNode,
+		Documentation: "Expressions of the form `CONTENT in PATH`, PATH being relative to the work's folder. The technology is considered used by a work if CONTENT is found in PATH.",
+	},
+}
+
+// repositoryKeyLine matches a line that only contains a (possibly partially-typed) mapping key of a repository entry, up to the cursor.
+var repositoryKeyLine = regexp.MustCompile(`^(\s*)(-\s+)?([\w ]*)$`)
+
+// repositoryFieldLine matches a line that defines a field of a repository entry, possibly starting a new entry.
+var repositoryFieldLine = regexp.MustCompile(`^(\s*)(-\s+)?([\w][\w ]*):`)
+
+// RepositoryFile represents a tags or technologies repository file, with a cursor position.
+type RepositoryFile struct {
+	// kind is either "tag" or "technology"
+	kind    string
+	lines   []string
+	cursor  protocol.Position
+	entries []yaml.Node
+	err     error
+}
+
+// ParseRepositoryFile parses the contents of a repository file with entries of the given kind.
+// Syntax errors are kept and reported by Diagnostics.
+func ParseRepositoryFile(kind string, contents string, cursor protocol.Position) RepositoryFile {
+	entries, err := ParseRepository([]byte(contents))
+	return RepositoryFile{
+		kind:    kind,
+		lines:   strings.Split(contents, "\n"),
+		cursor:  cursor,
+		entries: entries,
+		err:     err,
+	}
+}
+
+// repositoryKindOf returns the kind of entries defined in the file at uri, if it is one of the repository files.
+func (h Handler) repositoryKindOf(uri protocol.URI) (string, bool) {
+	current := h.stateOf(uri)
+	for _, kind := range []string{"tag", "technology"} {
+		if samePath(repositoryPath(current.config, kind), uri.Filename()) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// repositoryFile returns the repository file at uri, with the given cursor position.
+func (h Handler) repositoryFile(uri protocol.URI, cursor protocol.Position) (RepositoryFile, bool, error) {
+	kind, ok := h.repositoryKindOf(uri)
+	if !ok {
+		return RepositoryFile{}, false, nil
+	}
+
+	contents, err := h.Workspace.Contents(uri)
+	if err != nil {
+		return RepositoryFile{}, true, fmt.Errorf("while reading %s repository: %w", kind, err)
+	}
+
+	return ParseRepositoryFile(kind, contents, cursor), true, nil
+}
+
+func repositorySchema(kind string) []schemaKey {
+	if kind == "tag" {
+		return tagSchema
+	}
+	return technologySchema
+}
+
+// Diagnostics returns all problems found in the repository file: syntax errors, unknown fields, missing required fields and names shared by several entries.
+func (r RepositoryFile) Diagnostics() []protocol.Diagnostic {
+	if r.err != nil {
+		return []protocol.Diagnostic{yamlErrorDiagnostic(r.err, r.lines, "invalid-repository")}
+	}
+
+	diagnostics := make([]protocol.Diagnostic, 0)
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if entry.Kind != yaml.MappingNode {
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range:    protocol.Range{Start: positionOf(entry), End: endPositionOf(entry)},
+				Severity: protocol.DiagnosticSeverityError,
+				Code:     "wrong-kind",
+				Source:   diagnosticsSource,
+				Message:  fmt.Sprintf("%s should be a mapping, not a %s", r.kind, kindName(entry.Kind)),
+			})
+			continue
+		}
+
+		diagnostics = append(diagnostics, schemaDiagnostics(entry, repositorySchema(r.kind), "", "unknown "+r.kind+" field %q, it will be ignored")...)
+		diagnostics = append(diagnostics, r.missingFieldsDiagnostics(entry)...)
+	}
+	return append(diagnostics, r.duplicateNamesDiagnostics()...)
+}
+
+// missingFieldsDiagnostics reports every required field that entry does not define.
+func (r RepositoryFile) missingFieldsDiagnostics(entry *yaml.Node) []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	for _, key := range repositorySchema(r.kind) {
+		if !key.Required {
+			continue
+		}
+
+		if value, ok := fieldOf(entry, key.Name); ok && value.Value != "" {
+			continue
+		}
+
+		end := endPositionOf(entry)
+		if len(entry.Content) > 0 {
+			end = endPositionOf(entry.Content[0])
+		}
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Range:    protocol.Range{Start: positionOf(entry), End: end},
+			Severity: protocol.DiagnosticSeverityError,
+			Code:     "missing-field",
+			Source:   diagnosticsSource,
+			Message:  fmt.Sprintf("%s is missing the required field %q", r.kind, key.Name),
+		})
+	}
+	return diagnostics
+}
+
+// duplicateNamesDiagnostics reports names and aliases that are already used by a previous entry.
+// Names are compared loosely, the way ortfodb resolves them.
+func (r RepositoryFile) duplicateNamesDiagnostics() []protocol.Diagnostic {
+	diagnostics := make([]protocol.Diagnostic, 0)
+	definedBy := make(map[string]*yaml.Node)
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+
+		names := make(map[string]bool)
+		for _, node := range nameNodesOf(entry) {
+			name := strings.ToLower(node.Value)
+			if name == "" || names[name] {
+				continue
+			}
+			names[name] = true
+
+			other, ok := definedBy[name]
+			if !ok {
+				definedBy[name] = entry
+				continue
+			}
+
+			otherName := node.Value
+			if field, ok := fieldOf(other, primaryNameField(r.kind)); ok {
+				otherName = field.Value
+			}
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range:    protocol.Range{Start: positionOf(node), End: endPositionOf(node)},
+				Severity: protocol.DiagnosticSeverityWarning,
+				Code:     "duplicate-name",
+				Source:   diagnosticsSource,
+				Message:  fmt.Sprintf("%q already refers to %s %q, defined on line %d", node.Value, r.kind, otherName, other.Line),
+			})
+		}
+	}
+	return diagnostics
+}
+
+// FieldCompletionItems returns completion items for the fields of an entry, if the cursor is where a field is expected.
+// The lines around the cursor are used instead of the parsed entries, since the file is usually not valid YAML while a field is being typed.
+func (r RepositoryFile) FieldCompletionItems() []protocol.CompletionItem {
+	items := make([]protocol.CompletionItem, 0)
+	if int(r.cursor.Line) >= len(r.lines) {
+		return items
+	}
+
+	line := strings.TrimSuffix(r.lines[r.cursor.Line], "\r")
+	cursor := utf16ColumnToByte(line, r.cursor.Character)
+	match := repositoryKeyLine.FindStringSubmatch(line[:cursor])
+	if match == nil {
+		return items
+	}
+
+	column := len(match[1]) + len(match[2])
+	typed := match[3]
+	if column == 0 {
+		// entries are items of the top-level sequence, their fields are never at the start of a line
+		return items
+	}
+
+	keys := repositorySchema(r.kind)
+	parent, present := r.fieldsAround(int(r.cursor.Line), column, match[2] != "")
+	if parent != "" {
+		schema, ok := findSchemaKey(keys, parent)
+		if !ok || schema.Kind != yaml.MappingNode {
+			return items
+		}
+		keys = schema.Children
+	}
+
+	end := len(line)
+	if colon := strings.Index(line, ":"); colon != -1 {
+		end = colon + 1
+	}
+
+	for _, key := range keys {
+		if present[key.Name] && key.Name != typed {
+			continue
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:         key.Name,
+			Kind:          protocol.CompletionItemKindProperty,
+			Detail:        kindName(key.Kind),
+			Documentation: key.Description(),
+			TextEdit: &protocol.TextEdit{
+				Range:   lineRange(r.cursor.Line, line, column, end),
+				NewText: key.Name + ":",
+			},
+		})
+	}
+	return items
+}
+
+// fieldsAround returns the fields defined next to the given line, in the same mapping, and the key of that mapping if it is nested in an entry.
+// column is the column fields of that mapping start at, and startsEntry is true if the given line starts a new entry.
+func (r RepositoryFile) fieldsAround(line int, column int, startsEntry bool) (parent string, present map[string]bool) {
+	present = make(map[string]bool)
+	if !startsEntry {
+		for i := line - 1; i >= 0; i-- {
+			match := repositoryFieldLine.FindStringSubmatch(r.lines[i])
+			if match == nil {
+				continue
+			}
+
+			fieldColumn := len(match[1]) + len(match[2])
+			if fieldColumn < column {
+				parent = strings.TrimSpace(match[3])
+				break
+			}
+			if fieldColumn == column {
+				present[strings.TrimSpace(match[3])] = true
+				if match[2] != "" {
+					break
+				}
+			}
+		}
+	}
+
+	for i := line + 1; i < len(r.lines); i++ {
+		match := repositoryFieldLine.FindStringSubmatch(r.lines[i])
+		if match == nil {
+			continue
+		}
+
+		fieldColumn := len(match[1]) + len(match[2])
+		if fieldColumn < column || (fieldColumn == column && match[2] != "") {
+			break
+		}
+		if fieldColumn == column {
+			present[strings.TrimSpace(match[3])] = true
+		}
+	}
+	return parent, present
+}
+
+// KeysAtCursor returns the path of fields leading to the field the cursor is on, e.g. ["detect", "files"].
+// found is false if the cursor is not on a field of an entry.
+func (r RepositoryFile) KeysAtCursor() (path []string, found bool) {
+	entry, ok := EntryAt(r.entries, r.cursor)
+	if !ok {
+		return nil, false
+	}
+	return keysAt(entry, r.cursor, []string{})
+}
+
+// repositoryHover documents the field under the cursor, or describes the entry under the cursor along with the number of projects that use it.
+func (h Handler) repositoryHover(file RepositoryFile, uri protocol.URI) (*protocol.Hover, error) {
+	if file.err != nil {
+		return nil, nil
+	}
+
+	if path, onKey := file.KeysAtCursor(); onKey {
+		if key, ok := schemaKeyAt(repositorySchema(file.kind), path); ok {
+			return &protocol.Hover{
+				Contents: key.Description(),
+			}, nil
+		}
+		return nil, nil
+	}
+
+	target, found, err := h.referenceTargetAt(uri, file.cursor)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	references, err := h.ReferencesTo(target)
+	if err != nil {
+		return nil, fmt.Errorf("while searching references: %w", err)
+	}
+
+	projects := make(map[protocol.DocumentURI]bool)
+	for _, reference := range references {
+		projects[reference.URI] = true
+	}
+
+	contents := ReferrableDescription(target.item, descriptionOf(target.item))
+	switch len(projects) {
+	case 0:
+		contents.Value += "\nNot used by any project."
+	case 1:
+		contents.Value += "\nUsed by 1 project."
+	default:
+		contents.Value += fmt.Sprintf("\nUsed by %d projects.", len(projects))
+	}
+	return &protocol.Hover{
+		Contents: contents,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package languageserver
+
+import (
+	"slices"
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+const testTagsRepository = `- singular: website
+  plural: websites
+- singular: site
+  plural: sites
+  aliases: [Website]
+- plural: designs
+`
+
+func TestRepositoryFileDiagnostics(t *testing.T) {
+	diagnostics := ParseRepositoryFile("tag", testTagsRepository, protocol.Position{}).Diagnostics()
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d: %v", len(diagnostics), diagnostics)
+	}
+
+	expected := []struct {
+		code string
+		line uint32
+	}{
+		{"missing-field", 5},
+		{"duplicate-name", 4},
+	}
+	for i, e := range expected {
+		if diagnostics[i].Code != e.code || diagnostics[i].Range.Start.Line != e.line {
+			t.Errorf("expected %s on line %d, got %s on line %d", e.code, e.line, diagnostics[i].Code, diagnostics[i].Range.Start.Line)
+		}
+	}
+}
+
+func TestRepositoryFileSyntaxError(t *testing.T) {
+	// é is two bytes but one UTF-16 code unit
+	diagnostics := ParseRepositoryFile("tag", "- singular: café\n  plural: [cafés\n", protocol.Position{}).Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Code != "invalid-repository" {
+		t.Fatalf("expected a syntax error, got %v", diagnostics)
+	}
+	if expected := span(0, 0, 0, 16); diagnostics[0].Range != expected {
+		t.Errorf("expected the error to span %v, got %v", expected, diagnostics[0].Range)
+	}
+}
+
+func TestRepositoryFieldCompletionItems(t *testing.T) {
+	contents := "- singular: café\n  plural: cafés\n  de\n"
+	items := ParseRepositoryFile("tag", contents, protocol.Position{Line: 2, Character: 4}).FieldCompletionItems()
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		labels = append(labels, item.Label)
+		if expected := span(2, 2, 2, 4); item.TextEdit.Range != expected {
+			t.Errorf("expected %s to replace %v, got %v", item.Label, expected, item.TextEdit.Range)
+		}
+	}
+	if expected := []string{"description", "learn more at", "aliases", "detect"}; !slices.Equal(labels, expected) {
+		t.Errorf("expected %v, got %v", expected, labels)
+	}
+}
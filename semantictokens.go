@@ -0,0 +1,334 @@
+package languageserver
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// semanticTokenUnknown marks tags and technologies that are not defined in their repository.
+const semanticTokenUnknown protocol.SemanticTokenModifiers = "unknown"
+
+// semanticTokensLegend lists the token types and modifiers used, in the order their indices refer to.
+var semanticTokensLegend = protocol.SemanticTokensLegend{
+	TokenTypes: []protocol.SemanticTokenTypes{
+		protocol.SemanticTokenKeyword,
+		protocol.SemanticTokenNamespace,
+		protocol.SemanticTokenMacro,
+		protocol.SemanticTokenString,
+		protocol.SemanticTokenType,
+		protocol.SemanticTokenVariable,
+		protocol.SemanticTokenEnumMember,
+	},
+	TokenModifiers: []protocol.SemanticTokenModifiers{
+		protocol.SemanticTokenModifierDeclaration,
+		semanticTokenUnknown,
+	},
+}
+
+// footnoteReferencePattern matches footnote references: [^name]
+var footnoteReferencePattern = regexp.MustCompile(`\[\^[^\]]+\]`)
+
+// semanticToken is a token of a description file, with an absolute position.
+type semanticToken struct {
+	line      uint32
+	start     uint32
+	length    uint32
+	tokenType protocol.SemanticTokenTypes
+	modifiers []protocol.SemanticTokenModifiers
+}
+
+// semanticTokensResult is the last semantic tokens sent for a document, to compute deltas from.
+type semanticTokensResult struct {
+	id   string
+	data []uint32
+}
+
+// SemanticTokens returns the tokens of ortfo-specific syntax in the description file, sorted by position.
+func (d DescriptionFile) SemanticTokens(s state) []semanticToken {
+	tokens := make([]semanticToken, 0)
+	tokens = append(tokens, referrableTokens[ortfodb.Tag]("tag", d.frontmatterMappings["tags"], s.tags, s.degraded)...)
+	tokens = append(tokens, referrableTokens[ortfodb.Technology]("technology", d.frontmatterMappings["made with"], s.technologies, s.degraded)...)
+	if layout, ok := d.frontmatterMappings["layout"]; ok {
+		tokens = append(tokens, layoutTokens(&layout)...)
+	}
+
+	for _, embed := range d.MediaEmbeds() {
+		tokens = append(tokens,
+			semanticToken{line: embed.Span.Start.Line, start: embed.Span.Start.Character, length: 1, tokenType: protocol.SemanticTokenMacro},
+			tokenAt(embed.Range, protocol.SemanticTokenString),
+		)
+	}
+
+	sections := d.LanguageSections()
+	shared := []bodyBlock{}
+	if len(sections) > 0 && sections[0].Marker == -1 {
+		shared = sections[0].Blocks
+	}
+	usagePatterns := make(map[string]*regexp.Regexp)
+	for _, section := range sections {
+		for _, block := range section.Blocks {
+			if block.Kind == blockAbbreviation && usagePatterns[block.Name] == nil {
+				usagePatterns[block.Name] = abbreviationUsagePattern(block.Name)
+			}
+		}
+	}
+	for _, section := range sections {
+		if section.Marker != -1 {
+			line := strings.TrimSuffix(d.lineAt(section.Marker), "\r")
+			language := languageMarkerPattern.FindStringSubmatchIndex(line)
+			tokens = append(tokens,
+				semanticToken{line: uint32(section.Marker), length: 2, tokenType: protocol.SemanticTokenKeyword},
+				semanticToken{
+					line:      uint32(section.Marker),
+					start:     uint32(utf16Len(line[:language[2]])),
+					length:    uint32(utf16Len(strings.TrimSpace(line[language[2]:language[3]]))),
+					tokenType: protocol.SemanticTokenNamespace,
+				},
+			)
+		}
+		usages := make([]*regexp.Regexp, 0)
+		for _, block := range append(append([]bodyBlock{}, shared...), section.Blocks...) {
+			if block.Kind == blockAbbreviation {
+				usages = append(usages, usagePatterns[block.Name])
+			}
+		}
+		tokens = append(tokens, d.blockTokens(section.Blocks, usages)...)
+	}
+
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].line != tokens[j].line {
+			return tokens[i].line < tokens[j].line
+		}
+		return tokens[i].start < tokens[j].start
+	})
+
+	// tokens cannot overlap: keep the first one
+	deduplicated := make([]semanticToken, 0, len(tokens))
+	for _, token := range tokens {
+		if last := len(deduplicated) - 1; last >= 0 && deduplicated[last].line == token.line && deduplicated[last].start+deduplicated[last].length > token.start {
+			continue
+		}
+		deduplicated = append(deduplicated, token)
+	}
+	return deduplicated
+}
+
+// blockTokens returns the tokens of footnotes and abbreviations in blocks.
+// usages are the patterns of the abbreviations that apply to the language of blocks, including the ones shared by all languages, see abbreviationUsagePattern.
+func (d DescriptionFile) blockTokens(blocks []bodyBlock, usages []*regexp.Regexp) []semanticToken {
+	tokens := make([]semanticToken, 0)
+
+	for _, block := range blocks {
+		if block.Fenced {
+			continue
+		}
+
+		for i := block.Start; i <= block.End; i++ {
+			line := strings.TrimSuffix(d.lineAt(i), "\r")
+			switch {
+			case block.Kind == blockAbbreviation:
+				name := strings.Index(line, "[") + 1
+				tokens = append(tokens, semanticToken{
+					line:      uint32(i),
+					start:     uint32(utf16Len(line[:name])),
+					length:    uint32(utf16Len(block.Name)),
+					tokenType: protocol.SemanticTokenType,
+					modifiers: []protocol.SemanticTokenModifiers{protocol.SemanticTokenModifierDeclaration},
+				})
+				continue
+			case block.Kind == blockFootnote && i == block.Start:
+				definition := footnoteReferencePattern.FindStringIndex(line)
+				tokens = append(tokens, semanticToken{
+					line:      uint32(i),
+					start:     uint32(utf16Len(line[:definition[0]])),
+					length:    uint32(utf16Len(line[definition[0]:definition[1]])),
+					tokenType: protocol.SemanticTokenVariable,
+					modifiers: []protocol.SemanticTokenModifiers{protocol.SemanticTokenModifierDeclaration},
+				})
+				line = strings.Repeat(" ", definition[1]) + line[definition[1]:]
+			}
+
+			for _, reference := range footnoteReferencePattern.FindAllStringIndex(line, -1) {
+				tokens = append(tokens, semanticToken{
+					line:      uint32(i),
+					start:     uint32(utf16Len(line[:reference[0]])),
+					length:    uint32(utf16Len(line[reference[0]:reference[1]])),
+					tokenType: protocol.SemanticTokenVariable,
+				})
+			}
+
+			for _, usage := range usages {
+				for _, match := range abbreviationUsages(usage, line) {
+					tokens = append(tokens, semanticToken{
+						line:      uint32(i),
+						start:     uint32(utf16Len(line[:match[0]])),
+						length:    uint32(utf16Len(line[match[0]:match[1]])),
+						tokenType: protocol.SemanticTokenType,
+					})
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// abbreviationUsagePattern returns a pattern matching name where it is not part of a longer word, with name as its first submatch.
+// \b cannot be used, since abbreviations can start or end with characters that are not word characters, such as C++ or .NET.
+func abbreviationUsagePattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|\W)(` + regexp.QuoteMeta(name) + `)(?:\W|$)`)
+}
+
+// abbreviationUsages returns the start and end offsets of every usage in line of the abbreviation matched by pattern.
+func abbreviationUsages(pattern *regexp.Regexp, line string) [][2]int {
+	usages := make([][2]int, 0)
+	for offset := 0; offset < len(line); {
+		match := pattern.FindStringSubmatchIndex(line[offset:])
+		if match == nil {
+			break
+		}
+		usages = append(usages, [2]int{offset + match[2], offset + match[3]})
+		// the character following a usage can also precede the next one
+		offset += match[3]
+	}
+	return usages
+}
+
+// referrableTokens returns a token for every item of sequence, marked as unknown if no entry of repo is referred to by it.
+// Items are never marked as unknown when degraded is true, since repo might then be missing or outdated.
+func referrableTokens[T referrable](kind string, sequence yaml.Node, repo []yaml.Node, degraded bool) []semanticToken {
+	tokens := make([]semanticToken, 0)
+	if sequence.Kind != yaml.SequenceNode {
+		return tokens
+	}
+
+	for _, item := range sequence.Content {
+		if item.Kind != yaml.ScalarNode || item.Value == "" {
+			continue
+		}
+
+		token := tokenAt(protocol.Range{Start: positionOf(item), End: endPositionOf(item)}, protocol.SemanticTokenEnumMember)
+		if _, _, err := FindInRepository[T](item.Value, kind, repo); err != nil && !degraded {
+			token.modifiers = []protocol.SemanticTokenModifiers{semanticTokenUnknown}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// layoutTokens returns a token for every block reference of the layout.
+func layoutTokens(node *yaml.Node) []semanticToken {
+	tokens := make([]semanticToken, 0)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if layoutReferencePattern.MatchString(node.Value) {
+			tokens = append(tokens, tokenAt(protocol.Range{Start: positionOf(node), End: endPositionOf(node)}, protocol.SemanticTokenVariable))
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			tokens = append(tokens, layoutTokens(child)...)
+		}
+	}
+	return tokens
+}
+
+// tokenAt returns a token spanning a single-line range.
+func tokenAt(at protocol.Range, tokenType protocol.SemanticTokenTypes) semanticToken {
+	return semanticToken{
+		line:      at.Start.Line,
+		start:     at.Start.Character,
+		length:    at.End.Character - at.Start.Character,
+		tokenType: tokenType,
+	}
+}
+
+// encodeSemanticTokens encodes sorted tokens in the relative format of the LSP specification.
+// Only tokens that intersect lines first to last are encoded.
+func encodeSemanticTokens(tokens []semanticToken, first uint32, last uint32) []uint32 {
+	data := make([]uint32, 0, 5*len(tokens))
+	previousLine, previousStart := uint32(0), uint32(0)
+	for _, token := range tokens {
+		if token.line < first || token.line > last || token.length == 0 {
+			continue
+		}
+
+		deltaStart := token.start
+		if token.line == previousLine {
+			deltaStart = token.start - previousStart
+		}
+
+		modifiers := uint32(0)
+		for _, modifier := range token.modifiers {
+			for i, known := range semanticTokensLegend.TokenModifiers {
+				if known == modifier {
+					modifiers |= 1 << i
+				}
+			}
+		}
+
+		tokenType := uint32(0)
+		for i, known := range semanticTokensLegend.TokenTypes {
+			if known == token.tokenType {
+				tokenType = uint32(i)
+			}
+		}
+
+		data = append(data, token.line-previousLine, deltaStart, token.length, tokenType, modifiers)
+		previousLine, previousStart = token.line, token.start
+	}
+	return data
+}
+
+// semanticTokensEdits returns the edits that turn previous into current: a single edit replacing what is between their common prefix and suffix.
+func semanticTokensEdits(previous []uint32, current []uint32) []protocol.SemanticTokensEdit {
+	prefix := 0
+	for prefix < len(previous) && prefix < len(current) && previous[prefix] == current[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(previous)-prefix && suffix < len(current)-prefix && previous[len(previous)-1-suffix] == current[len(current)-1-suffix] {
+		suffix++
+	}
+
+	if prefix == len(previous) && prefix == len(current) {
+		return []protocol.SemanticTokensEdit{}
+	}
+
+	return []protocol.SemanticTokensEdit{
+		{
+			Start:       uint32(prefix),
+			DeleteCount: uint32(len(previous) - prefix - suffix),
+			Data:        current[prefix : len(current)-suffix],
+		},
+	}
+}
+
+// RememberSemanticTokens stores the semantic tokens sent for the document at uri, and returns the result ID to send along with them.
+func (w *Workspace) RememberSemanticTokens(uri protocol.URI, data []uint32) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.semanticTokens == nil {
+		w.semanticTokens = make(map[protocol.URI]semanticTokensResult)
+	}
+	w.semanticTokensResults++
+	id := strconv.Itoa(w.semanticTokensResults)
+	w.semanticTokens[uri] = semanticTokensResult{id: id, data: data}
+	return id
+}
+
+// PreviousSemanticTokens returns the semantic tokens last sent for the document at uri, if their result ID is id.
+func (w *Workspace) PreviousSemanticTokens(uri protocol.URI, id string) ([]uint32, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	previous, ok := w.semanticTokens[uri]
+	if !ok || previous.id != id {
+		return nil, false
+	}
+	return previous.data, true
+}
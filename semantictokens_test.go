@@ -0,0 +1,77 @@
+package languageserver
+
+import (
+	"reflect"
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	expected := []semanticToken{
+		{line: 4, start: 0, length: 1, tokenType: protocol.SemanticTokenMacro},
+		{line: 4, start: 16, length: 9, tokenType: protocol.SemanticTokenString},
+		{line: 6, start: 0, length: 2, tokenType: protocol.SemanticTokenKeyword},
+		{line: 6, start: 3, length: 2, tokenType: protocol.SemanticTokenNamespace},
+		{line: 20, start: 0, length: 2, tokenType: protocol.SemanticTokenKeyword},
+		{line: 20, start: 3, length: 2, tokenType: protocol.SemanticTokenNamespace},
+		{line: 24, start: 8, length: 4, tokenType: protocol.SemanticTokenVariable},
+		{line: 26, start: 0, length: 4, tokenType: protocol.SemanticTokenVariable, modifiers: []protocol.SemanticTokenModifiers{protocol.SemanticTokenModifierDeclaration}},
+		{line: 28, start: 2, length: 4, tokenType: protocol.SemanticTokenType, modifiers: []protocol.SemanticTokenModifiers{protocol.SemanticTokenModifierDeclaration}},
+	}
+
+	tokens := ParseDescriptionFile(testBilingualDescription, protocol.Position{}).SemanticTokens(state{})
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected tokens\n%+v\ngot\n%+v", expected, tokens)
+	}
+
+	data := encodeSemanticTokens(tokens[:3], 0, 10)
+	if expectedData := []uint32{4, 0, 1, 2, 0, 0, 16, 9, 3, 0, 2, 0, 2, 0, 0}; !reflect.DeepEqual(data, expectedData) {
+		t.Errorf("expected data %v, got %v", expectedData, data)
+	}
+}
+
+func TestSemanticTokensEdits(t *testing.T) {
+	edits := semanticTokensEdits([]uint32{1, 2, 3, 4, 5}, []uint32{1, 2, 9, 9, 4, 5})
+	expected := []protocol.SemanticTokensEdit{{Start: 2, DeleteCount: 1, Data: []uint32{9, 9}}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("expected %+v, got %+v", expected, edits)
+	}
+
+	if edits := semanticTokensEdits([]uint32{1, 2}, []uint32{1, 2}); len(edits) != 0 {
+		t.Errorf("expected no edits, got %+v", edits)
+	}
+}
+
+func TestAbbreviationUsages(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		line     string
+		expected [][2]int
+	}{
+		{"API", "APIs use an API API", [][2]int{{12, 15}, {16, 19}}},
+		{"C++", "C++ and C++/CLI, not C++20", [][2]int{{0, 3}, {8, 11}}},
+		{".NET", "with .NET, not ASP.NET", [][2]int{{5, 9}}},
+		{"HTML", "no abbreviation here", [][2]int{}},
+	} {
+		usages := abbreviationUsages(abbreviationUsagePattern(test.name), test.line)
+		if !reflect.DeepEqual(usages, test.expected) {
+			t.Errorf("%q in %q: expected %v, got %v", test.name, test.line, test.expected, usages)
+		}
+	}
+}
+
+func TestReferrableTokensWhenDegraded(t *testing.T) {
+	file := ParseDescriptionFile("---\ntags: [web]\n---\n", protocol.Position{})
+	unknown := semanticToken{line: 1, start: 7, length: 3, tokenType: protocol.SemanticTokenEnumMember, modifiers: []protocol.SemanticTokenModifiers{semanticTokenUnknown}}
+	if tokens := file.SemanticTokens(state{}); !reflect.DeepEqual(tokens, []semanticToken{unknown}) {
+		t.Errorf("expected %+v, got %+v", unknown, tokens)
+	}
+
+	// the repositories could not be loaded: nothing is known to be unknown
+	known := unknown
+	known.modifiers = nil
+	if tokens := file.SemanticTokens(state{degraded: true}); !reflect.DeepEqual(tokens, []semanticToken{known}) {
+		t.Errorf("expected %+v, got %+v", known, tokens)
+	}
+}
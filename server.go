@@ -2,10 +2,13 @@ package languageserver
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 
+	ortfodb "github.com/ortfo/db"
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
 	"go.uber.org/multierr"
@@ -14,58 +17,151 @@ import (
 
 var BuiltAt string
 
-// StartServer starts the language server. It reads from stdin and writes to stdout.
-// If logClientIn is not empty, it will log the client's request and responses to respectively client-request-from.log and client-response-to.log, in the directory specified by logClientIn.
-func StartServer(logger *zap.Logger, configurationPath string, logClientIn string) {
-	conn := jsonrpc2.NewConn(jsonrpc2.NewStream(&readWriteCloser{
-		reader: os.Stdin,
-		writer: os.Stdout,
-		logAt:  logClientIn,
-	}))
-	// notifier := protocol.ClientDispatcher(conn, logger.Named("notify"))
-	// handler := languageserver.Handler{
-	// 	Logger: logger,
-	// 	Server: protocol.ServerDispatcher(conn, logger),
-	// }
-	handler, ctx, err := NewHandler(context.WithValue(context.Background(), "configpath", configurationPath), protocol.ServerDispatcher(conn, logger), logger)
+// Transports the server can talk to the client over.
+const (
+	TransportStdio  = "stdio"
+	TransportTCP    = "tcp"
+	TransportSocket = "socket"
+)
+
+// Transport is how the server talks to the client.
+type Transport struct {
+	// Kind is one of TransportStdio, TransportTCP or TransportSocket.
+	Kind string
+	// Address is the host:port to listen on with TransportTCP, and the path of the unix socket to listen on with TransportSocket.
+	Address string
+}
+
+func (t Transport) String() string {
+	if t.Address == "" {
+		return t.Kind
+	}
+	return t.Kind + ":" + t.Address
+}
+
+// Version returns the version of the server, as shown by clients.
+func Version() string {
+	return fmt.Sprintf("%s (built at %s)", ortfodb.Version, BuiltAt)
+}
+
+// StartServer starts the language server on the given transport.
+// The ortfodb configuration is looked for in the client's workspace folders and above opened files, configurationPath is only used when none is found.
+// With TCP and unix sockets, every accepted connection is served by its own handler, until listening fails.
+// If traceDir is not empty, it will log the client's request and responses to respectively client-request-from.log and client-response-to.log, in that directory.
+// With TCP and unix sockets, the number of the connection is added to these names, starting from 1: client-request-from.1.log, and so on.
+func StartServer(log *zap.Logger, configurationPath string, transport Transport, traceDir string) error {
+	// set once, before any connection is served: handlers log with their own logger
+	logger = log
+
+	var network string
+	switch transport.Kind {
+	case TransportStdio, "":
+		serve(logger, configurationPath, os.Stdin, os.Stdout, traceDir, 0)
+		return nil
+	case TransportTCP:
+		network = "tcp"
+	case TransportSocket:
+		network = "unix"
+	default:
+		return fmt.Errorf("unknown transport %q", transport.Kind)
+	}
+
+	listener, err := net.Listen(network, transport.Address)
 	if err != nil {
-		logger.Sugar().Fatalf("while initializing handler: %w", err)
+		return fmt.Errorf("while listening on %s: %w", transport, err)
 	}
+	defer listener.Close()
+	logger.Info("Listening for clients", zap.Stringer("transport", transport))
+
+	for connection := 1; ; connection++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("while accepting connection on %s: %w", transport, err)
+		}
 
-	conn.Go(ctx, protocol.ServerHandler(handler, jsonrpc2.MethodNotFoundHandler))
+		go serve(logger.With(zap.Stringer("client", conn.RemoteAddr()), zap.Int("connection", connection)), configurationPath, conn, conn, traceDir, connection)
+	}
+}
+
+// serve runs a language server that reads from reader and writes to writer, until the connection is closed.
+// connection is the number of the connection, used to name its trace files; it is 0 when there can only be one.
+func serve(logger *zap.Logger, configurationPath string, reader io.ReadCloser, writer io.WriteCloser, traceDir string, connection int) {
+	conn := jsonrpc2.NewConn(jsonrpc2.NewStream(newReadWriteCloser(logger, reader, writer, traceDir, connection)))
+	handler := NewHandler(configurationPath, protocol.ServerDispatcher(conn, logger), protocol.ClientDispatcher(conn, logger), logger)
+
+	conn.Go(context.Background(), protocol.ServerHandler(handler, jsonrpc2.MethodNotFoundHandler))
 	<-conn.Done()
 }
 
 type readWriteCloser struct {
-	reader io.ReadCloser
-	writer io.WriteCloser
-	logAt  string
+	reader     io.ReadCloser
+	writer     io.WriteCloser
+	logAt      string
+	connection int
+	// requests and responses are the trace files, nil when not tracing
+	requests  io.WriteCloser
+	responses io.WriteCloser
 }
 
-func (r *readWriteCloser) Read(b []byte) (int, error) {
-	var f *os.File
-	if r.logAt != "" {
-		f, _ = os.OpenFile(filepath.Join(r.logAt, "client-request-from.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// newReadWriteCloser returns a stream reading from reader and writing to writer.
+// If traceDir is not empty, the trace files of the connection are opened there, once for the whole connection; if they cannot be opened, the connection is not traced.
+func newReadWriteCloser(logger *zap.Logger, reader io.ReadCloser, writer io.WriteCloser, traceDir string, connection int) *readWriteCloser {
+	r := &readWriteCloser{reader: reader, writer: writer, logAt: traceDir, connection: connection}
+	if traceDir == "" {
+		return r
+	}
+
+	requests, err := os.OpenFile(r.tracePath("client-request-from"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Warn("could not open trace file, not tracing", zap.Error(err))
+		return r
+	}
+	responses, err := os.OpenFile(r.tracePath("client-response-to"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Warn("could not open trace file, not tracing", zap.Error(err))
+		requests.Close()
+		return r
 	}
+	r.requests, r.responses = requests, responses
+	return r
+}
+
+// tracePath returns the path of the trace file with the given name in the trace directory, numbered with the connection if there can be several.
+func (r *readWriteCloser) tracePath(name string) string {
+	if r.connection > 0 {
+		name = fmt.Sprintf("%s.%d", name, r.connection)
+	}
+	return filepath.Join(r.logAt, name+".log")
+}
+
+func (r *readWriteCloser) Read(b []byte) (int, error) {
 	n, err := r.reader.Read(b)
-	if r.logAt != "" {
+	if r.requests != nil {
+		r.requests.Write(b[:n])
 		if err != nil {
-			f.Write([]byte(err.Error() + "\n"))
-		} else {
-			f.Write(b)
+			r.requests.Write([]byte(err.Error() + "\n"))
 		}
 	}
 	return n, err
 }
 
 func (r *readWriteCloser) Write(b []byte) (int, error) {
-	if r.logAt != "" {
-		f, _ := os.OpenFile(filepath.Join(r.logAt, "client-response-to.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		f.Write(b)
+	if r.responses != nil {
+		r.responses.Write(b)
 	}
 	return r.writer.Write(b)
 }
 
 func (r *readWriteCloser) Close() error {
-	return multierr.Append(r.reader.Close(), r.writer.Close())
+	var err error
+	if io.Closer(r.reader) == io.Closer(r.writer) {
+		// network connections are both the reader and the writer
+		err = r.reader.Close()
+	} else {
+		err = multierr.Append(r.reader.Close(), r.writer.Close())
+	}
+	if r.requests != nil {
+		err = multierr.Combine(err, r.requests.Close(), r.responses.Close())
+	}
+	return err
 }
@@ -0,0 +1,89 @@
+package languageserver
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// stringReadCloser reads from a string, and remembers whether it was closed.
+type stringReadCloser struct {
+	*strings.Reader
+	closed bool
+}
+
+func (s *stringReadCloser) Close() error {
+	s.closed = true
+	return nil
+}
+
+type bufferWriteCloser struct {
+	strings.Builder
+	closed bool
+}
+
+func (b *bufferWriteCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestReadWriteCloserTraces(t *testing.T) {
+	traceDir := t.TempDir()
+	reader := &stringReadCloser{Reader: strings.NewReader("request")}
+	writer := &bufferWriteCloser{}
+	stream := newReadWriteCloser(zap.NewNop(), reader, writer, traceDir, 3)
+
+	// the buffer is larger than what is read
+	buffer := make([]byte, 64)
+	for {
+		if _, err := stream.Read(buffer[:4]); err == io.EOF {
+			break
+		}
+	}
+	for _, response := range []string{"first ", "second"} {
+		if _, err := stream.Write([]byte(response)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reader.closed || !writer.closed || writer.String() != "first second" {
+		t.Errorf("expected the reader and the writer to be closed and written to, got %v, %v and %q", reader.closed, writer.closed, writer.String())
+	}
+	for name, expected := range map[string]string{
+		"client-request-from.3.log": "request" + io.EOF.Error() + "\n",
+		"client-response-to.3.log":  "first second",
+	} {
+		contents, err := os.ReadFile(filepath.Join(traceDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != expected {
+			t.Errorf("expected %s to contain %q, got %q", name, expected, contents)
+		}
+	}
+
+	// the trace files are closed with the stream
+	if _, err := stream.requests.Write([]byte("late")); err == nil {
+		t.Errorf("expected the requests trace file to be closed")
+	}
+	if _, err := stream.responses.Write([]byte("late")); err == nil {
+		t.Errorf("expected the responses trace file to be closed")
+	}
+}
+
+func TestReadWriteCloserWithoutTraces(t *testing.T) {
+	stream := newReadWriteCloser(zap.NewNop(), &stringReadCloser{Reader: strings.NewReader("")}, &bufferWriteCloser{}, "", 0)
+	if stream.requests != nil || stream.responses != nil {
+		t.Errorf("expected no trace files without a trace directory")
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+package languageserver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Settings are the user-configurable behaviors of the server.
+// Clients send them as initialization options, and then under the "ortfo" section of workspace/didChangeConfiguration notifications.
+type Settings struct {
+	Rename struct {
+		// KeepOldNameAsAlias adds the previous name of a renamed tag or technology to its aliases.
+		KeepOldNameAsAlias bool `json:"keepOldNameAsAlias"`
+	} `json:"rename"`
+	Aliases struct {
+		// CanonicalName is the name tags and technologies should be referred to by in frontmatters: "urlFriendlyName", "displayName", or "off" to accept any of their names.
+		CanonicalName string `json:"canonicalName"`
+	} `json:"aliases"`
+}
+
+// canonicalName returns the aliases.canonicalName setting, which is off by default: teams that want a canonical name opt into it.
+func (s Settings) canonicalName() string {
+	if s.Aliases.CanonicalName == "" {
+		return canonicalOff
+	}
+	return s.Aliases.CanonicalName
+}
+
+// decodeSettings decodes settings sent by the client, which are arbitrary JSON values.
+func decodeSettings(raw interface{}) (Settings, error) {
+	var settings Settings
+	if raw == nil {
+		return settings, nil
+	}
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return settings, fmt.Errorf("while encoding settings: %w", err)
+	}
+
+	if err := json.Unmarshal(encoded, &settings); err != nil {
+		return settings, fmt.Errorf("while decoding settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Settings returns the current settings.
+func (w *Workspace) Settings() Settings {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.settings
+}
+
+// SetSettings replaces the current settings.
+func (w *Workspace) SetSettings(settings Settings) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.settings = settings
+}
@@ -0,0 +1,91 @@
+package languageserver
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.lsp.dev/protocol"
+)
+
+// applyContentChange returns contents with the given change applied.
+// Changes that replace the whole document have no range, which decodes to an empty range at the start of the document with a zero range length.
+func applyContentChange(contents string, change protocol.TextDocumentContentChangeEvent) (string, error) {
+	if change.Range == (protocol.Range{}) && change.RangeLength == 0 {
+		return change.Text, nil
+	}
+
+	start, err := byteOffset(contents, change.Range.Start)
+	if err != nil {
+		return contents, fmt.Errorf("while locating start of change: %w", err)
+	}
+
+	end, err := byteOffset(contents, change.Range.End)
+	if err != nil {
+		return contents, fmt.Errorf("while locating end of change: %w", err)
+	}
+
+	if end < start {
+		return contents, fmt.Errorf("change ends at %d, before its start at %d", end, start)
+	}
+
+	return contents[:start] + change.Text + contents[end:], nil
+}
+
+// byteOffset returns the offset in bytes of position in contents.
+// Characters in position are counted in UTF-16 code units, as mandated by the LSP specification.
+// Positions past the end of a line are clamped to the end of that line.
+func byteOffset(contents string, position protocol.Position) (int, error) {
+	offset := 0
+	for line := uint32(0); line < position.Line; line++ {
+		newline := strings.IndexByte(contents[offset:], '\n')
+		if newline == -1 {
+			return 0, fmt.Errorf("line %d is out of range", position.Line)
+		}
+		offset += newline + 1
+	}
+
+	lineEnd := strings.IndexByte(contents[offset:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(contents) - offset
+	}
+	line := strings.TrimSuffix(contents[offset:offset+lineEnd], "\r")
+
+	return offset + utf16ColumnToByte(line, position.Character), nil
+}
+
+// lineRange returns the range between the byte offsets start and end of line, the text of line number lineNumber, with characters counted in UTF-16 code units.
+func lineRange(lineNumber uint32, line string, start int, end int) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: lineNumber, Character: uint32(utf16Len(line[:start]))},
+		End:   protocol.Position{Line: lineNumber, Character: uint32(utf16Len(line[:end]))},
+	}
+}
+
+// utf16ColumnToByte converts a column counted in UTF-16 code units to an offset in bytes inside line.
+func utf16ColumnToByte(line string, column uint32) int {
+	units := uint32(0)
+	for i, r := range line {
+		if units >= column {
+			return i
+		}
+		units += uint32(utf16Length(r))
+	}
+	return len(line)
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	length := 0
+	for _, r := range s {
+		length += utf16Length(r)
+	}
+	return length
+}
+
+func utf16Length(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
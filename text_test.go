@@ -0,0 +1,49 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestApplyContentChange(t *testing.T) {
+	cases := []struct {
+		contents string
+		change   protocol.TextDocumentContentChangeEvent
+		expected string
+	}{
+		{"hello world", protocol.TextDocumentContentChangeEvent{Range: span(0, 6, 0, 11), Text: "there"}, "hello there"},
+		{"a\nb\nc", protocol.TextDocumentContentChangeEvent{Range: span(1, 0, 2, 0), Text: ""}, "a\nc"},
+		{"a\r\nb", protocol.TextDocumentContentChangeEvent{Range: span(0, 1, 0, 1), Text: "!"}, "a!\r\nb"},
+		// é is one UTF-16 code unit but two bytes
+		{"café bar", protocol.TextDocumentContentChangeEvent{Range: span(0, 5, 0, 8), Text: "tabac"}, "café tabac"},
+		// 🎨 is two UTF-16 code units and four bytes
+		{"🎨 art", protocol.TextDocumentContentChangeEvent{Range: span(0, 2, 0, 2), Text: "!"}, "🎨! art"},
+		{"short", protocol.TextDocumentContentChangeEvent{Range: span(0, 100, 0, 100), Text: "er"}, "shorter"},
+		// whole document changes have no range
+		{"a\nb", protocol.TextDocumentContentChangeEvent{Text: "c\nd\n"}, "c\nd\n"},
+		{"a\nb", protocol.TextDocumentContentChangeEvent{Text: ""}, ""},
+	}
+
+	for _, c := range cases {
+		result, err := applyContentChange(c.contents, c.change)
+		if err != nil {
+			t.Errorf("applying %#v to %q: %s", c.change, c.contents, err)
+			continue
+		}
+		if result != c.expected {
+			t.Errorf("applying %#v to %q: expected %q, got %q", c.change, c.contents, c.expected, result)
+		}
+	}
+}
+
+func span(startLine, startCharacter, endLine, endCharacter uint32) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: startLine, Character: startCharacter},
+		End:   protocol.Position{Line: endLine, Character: endCharacter},
+	}
+}
+
+func wholeLine(line, length uint32) protocol.Range {
+	return span(line, 0, line, length)
+}
@@ -20,6 +20,7 @@ let client: LanguageClient
 export function activate(context: ExtensionContext) {
   const serverModule = "ortfodb"
 
+  // Only used by the server when no ortfodb.yaml is found in the workspace folders or above opened description files
   const configurationFilepath: string = workspace
     .getConfiguration("ortfo")
     .get("globalConfigPath")
@@ -27,15 +28,15 @@ export function activate(context: ExtensionContext) {
   const configurationHome = path.dirname(configurationFilepath)
 
   console.log(
-    `Running ortfo extension with configuration file at: ${configurationFilepath}`
+    `Running ortfo extension with fallback configuration file at: ${configurationFilepath}`
   )
 
-  const configuration = loadConfiguration(configurationFilepath)
-  if (!configuration || !configuration.tags || !configuration.technologies) {
-    throw new Error(
-      `Could not load configuration from ${configurationFilepath}: ${JSON.stringify(
-        configuration
-      )} is missing a value for tags.repository or technologies.repository`
+  let configuration: ReturnType<typeof loadConfiguration> | undefined
+  try {
+    configuration = loadConfiguration(configurationFilepath)
+  } catch (error) {
+    console.log(
+      `Could not load fallback configuration from ${configurationFilepath}, relying on ortfodb.yaml files of the workspace: ${error}`
     )
   }
 
@@ -49,17 +50,32 @@ export function activate(context: ExtensionContext) {
     },
     debug: {
       command: "/home/uwun/.local/bin/ortfols",
-      args: [configurationFilepath],
+      args: ["-c", configurationFilepath, "--log-level", "debug", "lsp"],
       transport: TransportKind.stdio,
     },
   }
 
-  const watchPattern = new RelativePattern(
-    configurationHome,
-    `{${relativePathsToRepositories(configurationHome, configuration).join(
-      ","
-    )}}`
-  )
+  const fileWatchers = [
+    // ortfodb.yaml files and repositories of the portfolios found in the workspace, and description files
+    workspace.createFileSystemWatcher("**/*.yaml"),
+    workspace.createFileSystemWatcher("**/description.md"),
+  ]
+  if (configuration?.tags && configuration?.technologies) {
+    fileWatchers.push(
+      workspace.createFileSystemWatcher(
+        new RelativePattern(
+          configurationHome,
+          `{${[
+            path.basename(configurationFilepath),
+            ...relativePathsToRepositories(configurationHome, configuration),
+          ].join(",")}}`
+        )
+      ),
+      workspace.createFileSystemWatcher(
+        new RelativePattern(configurationHome, "**/description.md")
+      )
+    )
+  }
 
   // Options to control the language client
   const clientOptions: LanguageClientOptions = {
@@ -69,9 +85,12 @@ export function activate(context: ExtensionContext) {
       { scheme: "file", language: "yaml" },
     ],
     outputChannelName: "ortfols",
+    initializationOptions: workspace.getConfiguration("ortfo"),
     synchronize: {
-      // Notify the server about file changes to '.clientrc files contained in the workspace
-      fileEvents: workspace.createFileSystemWatcher(watchPattern),
+      configurationSection: "ortfo",
+      // Notify the server about changes to ortfodb.yaml and the tags and technologies repositories, so that it can reload them,
+      // and about changes to description files, so that it can keep its index of projects up to date
+      fileEvents: fileWatchers,
     },
   }
 
@@ -0,0 +1,190 @@
+package languageserver
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	ortfodb "github.com/ortfo/db"
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// state is a snapshot of the ortfodb configuration and the repositories it points to.
+// It is never modified once loaded: reloading replaces it with a new one.
+type state struct {
+	config       ortfodb.Configuration
+	tags         []yaml.Node
+	technologies []yaml.Node
+	// degraded is true when the configuration or one of the repositories could not be loaded, so that references to tags and technologies cannot be checked.
+	degraded bool
+}
+
+// Document is a text document that is open in the editor.
+type Document struct {
+	URI protocol.URI
+	// Version is the version number sent by the client, it increases after each change.
+	Version  int32
+	Contents string
+	// parsed caches the result of parsing Contents. It is computed the first time it is needed.
+	parsed *DescriptionFile
+}
+
+// Workspace holds the portfolios and the documents opened by the client.
+// It is safe for concurrent use.
+type Workspace struct {
+	mu sync.RWMutex
+	// portfolios are in the order they were loaded in. Files that belong to none of them are handled with the first one.
+	portfolios []*portfolio
+	// folders maps the paths of the client's workspace folders to the ortfodb configuration found for them.
+	folders   map[string]string
+	settings  Settings
+	documents map[protocol.URI]Document
+	// semanticTokens holds the last semantic tokens sent for each open document, semanticTokensResults counts them to give them unique IDs.
+	semanticTokens        map[protocol.URI]semanticTokensResult
+	semanticTokensResults int
+	logger                *zap.Logger
+}
+
+// NewWorkspace loads the ortfodb configuration at configPath along with its repositories.
+func NewWorkspace(logger *zap.Logger, configPath string) (*Workspace, error) {
+	workspace := newWorkspace(logger)
+	if err := workspace.Load(configPath); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// newWorkspace returns a workspace with no portfolio loaded: states are empty until Load succeeds.
+func newWorkspace(logger *zap.Logger) *Workspace {
+	return &Workspace{
+		logger:    logger,
+		folders:   make(map[string]string),
+		documents: make(map[protocol.URI]Document),
+	}
+}
+
+// loadState loads the ortfodb configuration at configPath, and the tags and technologies repositories it points to.
+// If one of the repositories cannot be loaded, the state loaded so far is returned along with a *loadError.
+func loadState(configPath string) (state, error) {
+	config, err := loadConfiguration(configPath)
+	if err != nil {
+		return state{degraded: true}, err
+	}
+
+	tags, err := LoadRepository(config.Tags.Repository)
+	if err != nil {
+		return state{config: config, degraded: true}, newLoadError(config.Tags.Repository, fmt.Errorf("while loading tags from repository: %w", err))
+	}
+
+	technologies, err := LoadRepository(config.Technologies.Repository)
+	if err != nil {
+		return state{config: config, tags: tags, degraded: true}, newLoadError(config.Technologies.Repository, fmt.Errorf("while loading technologies from repository: %w", err))
+	}
+
+	return state{
+		config:       config,
+		tags:         tags,
+		technologies: technologies,
+	}, nil
+}
+
+// Open starts tracking the document at uri.
+func (w *Workspace) Open(uri protocol.URI, version int32, contents string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.documents[uri] = Document{URI: uri, Version: version, Contents: contents}
+}
+
+// Edit applies changes to the document at uri, either incremental or replacing its whole contents.
+// Changes are applied in order, each one relative to the result of the previous one.
+func (w *Workspace) Edit(uri protocol.URI, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	document, ok := w.documents[uri]
+	if !ok {
+		return fmt.Errorf("document %s is not open", uri)
+	}
+
+	if document.Version > version {
+		w.logger.Debug("ignoring outdated document edit", zap.Any("uri", uri), zap.Int32("version", version), zap.Int32("current version", document.Version))
+		return nil
+	}
+
+	contents := document.Contents
+	for _, change := range changes {
+		var err error
+		contents, err = applyContentChange(contents, change)
+		if err != nil {
+			return fmt.Errorf("while applying change %#v to %s: %w", change, uri, err)
+		}
+	}
+
+	w.documents[uri] = Document{URI: uri, Version: version, Contents: contents}
+	return nil
+}
+
+// Close stops tracking the document at uri.
+func (w *Workspace) Close(uri protocol.URI) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.documents, uri)
+	delete(w.semanticTokens, uri)
+}
+
+// Document returns the open document at uri.
+func (w *Workspace) Document(uri protocol.URI) (Document, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	document, ok := w.documents[uri]
+	return document, ok
+}
+
+// OpenDocuments returns the URIs of all open documents.
+func (w *Workspace) OpenDocuments() []protocol.URI {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return keys(w.documents)
+}
+
+// Contents returns the contents of the document at uri, reading it from disk if it is not open.
+func (w *Workspace) Contents(uri protocol.URI) (string, error) {
+	if document, ok := w.Document(uri); ok {
+		return document.Contents, nil
+	}
+
+	w.logger.Debug("loading from disk", zap.Any("uri", uri))
+	contents, err := os.ReadFile(uri.Filename())
+	if err != nil {
+		return "", fmt.Errorf("while reading file at %s: %w", uri.Filename(), err)
+	}
+	return string(contents), nil
+}
+
+// CurrentFile parses the description file at uri, with the cursor at the given position.
+// Open documents are only parsed again when they changed since the last call.
+func (w *Workspace) CurrentFile(uri protocol.URI, cursor protocol.Position) (DescriptionFile, error) {
+	document, open := w.Document(uri)
+	if !open {
+		contents, err := w.Contents(uri)
+		if err != nil {
+			return DescriptionFile{}, fmt.Errorf("while loading file: %w", err)
+		}
+		return ParseDescriptionFile(contents, cursor), nil
+	}
+
+	if document.parsed == nil {
+		parsed := ParseDescriptionFile(document.Contents, protocol.Position{})
+		document.parsed = &parsed
+		w.mu.Lock()
+		if current, ok := w.documents[uri]; ok && current.Version == document.Version {
+			w.documents[uri] = document
+		}
+		w.mu.Unlock()
+	}
+
+	file := *document.parsed
+	file.cursor = cursor
+	return file, nil
+}
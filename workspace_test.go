@@ -0,0 +1,30 @@
+package languageserver
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+	"go.uber.org/zap"
+)
+
+func TestWorkspaceIgnoresOutdatedEdits(t *testing.T) {
+	workspace := newWorkspace(zap.NewNop())
+	uri := protocol.URI("file:///portfolio/project/description.md")
+
+	workspace.Open(uri, 1, "first")
+	workspace.Edit(uri, 3, []protocol.TextDocumentContentChangeEvent{{Range: wholeLine(0, 5), Text: "third"}})
+	workspace.Edit(uri, 2, []protocol.TextDocumentContentChangeEvent{{Range: wholeLine(0, 5), Text: "second"}})
+
+	document, ok := workspace.Document(uri)
+	if !ok {
+		t.Fatalf("document %s should be open", uri)
+	}
+	if document.Contents != "third" || document.Version != 3 {
+		t.Errorf("expected version 3 with contents %q, got version %d with contents %q", "third", document.Version, document.Contents)
+	}
+
+	workspace.Close(uri)
+	if _, ok := workspace.Document(uri); ok {
+		t.Errorf("document %s should be closed", uri)
+	}
+}
@@ -1,11 +1,50 @@
 package languageserver
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
 
+// yamlErrorLine extracts the line number from error messages of gopkg.in/yaml.v3.
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// yamlErrorPosition returns the start of the line err was reported on by gopkg.in/yaml.v3, or the start of the document if err does not come from it.
+func yamlErrorPosition(err error) protocol.Position {
+	match := yamlErrorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return protocol.Position{}
+	}
+	line, _ := strconv.Atoi(match[1])
+	return protocol.Position{Line: uint32(max(line-1, 0))}
+}
+
+// yamlErrorDiagnostic returns an error diagnostic with the given code reporting err on the whole line gopkg.in/yaml.v3 reported it on.
+// lines are the lines of the document that was parsed.
+func yamlErrorDiagnostic(err error, lines []string, code string) protocol.Diagnostic {
+	return errorDiagnosticAt(yamlErrorPosition(err), err, lines, code)
+}
+
+// errorDiagnosticAt returns an error diagnostic with the given code reporting err from start to the end of its line.
+func errorDiagnosticAt(start protocol.Position, err error, lines []string, code string) protocol.Diagnostic {
+	end := start
+	if int(start.Line) < len(lines) {
+		end = endOfLine(lines, start.Line)
+	}
+	return protocol.Diagnostic{
+		Range:    protocol.Range{Start: start, End: end},
+		Severity: protocol.DiagnosticSeverityError,
+		Code:     code,
+		Source:   diagnosticsSource,
+		Message:  err.Error(),
+	}
+}
+
 func positionOf(node *yaml.Node) protocol.Position {
 	return protocol.Position{
 		Line:      uint32(node.Line) - 1,
@@ -13,10 +52,15 @@ func positionOf(node *yaml.Node) protocol.Position {
 	}
 }
 
-func endPositionOf(node *yaml.Node) protocol.Position{
+func endPositionOf(node *yaml.Node) protocol.Position {
+	length := utf16Len(node.Value)
+	if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+		// account for the quotes
+		length += 2
+	}
 	return protocol.Position{
 		Line:      uint32(node.Line) - 1,
-		Character: uint32(node.Column) - 1 + uint32(len(node.Value)),
+		Character: uint32(node.Column) - 1 + uint32(length),
 	}
 }
 
@@ -44,20 +88,72 @@ func comparePositions(a protocol.Position, b protocol.Position) int {
 	return int(a.Line) - int(b.Line)
 }
 
-func closestNodeInArray(node *yaml.Node, pos protocol.Position) *yaml.Node {
-	logger.Debug("closestNodeInArray", zap.Any("node", node), zap.Any("pos", pos))
-	for i, child := range node.Content {
-		if i+1 >= len(node.Content) {
-			logger.Debug("closestNodeInArray:on last child", zap.Any("child", child))
-			return child
+// yamlPathSegment is a step of a YAMLPath: either a mapping key, or an index in a sequence.
+type yamlPathSegment struct {
+	key string
+	// index is -1 for mapping keys
+	index int
+}
+
+// YAMLPath locates a node inside a YAML document, e.g. colors.primary or layout[2][1]. Indices are 0-based.
+type YAMLPath []yamlPathSegment
+
+func (p YAMLPath) String() string {
+	var result strings.Builder
+	for i, segment := range p {
+		if segment.index >= 0 {
+			fmt.Fprintf(&result, "[%d]", segment.index)
+			continue
+		}
+		if i > 0 {
+			result.WriteString(".")
 		}
+		result.WriteString(segment.key)
+	}
+	return result.String()
+}
+
+// Keys returns the mapping keys of the path, without sequence indices.
+func (p YAMLPath) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for _, segment := range p {
+		if segment.index < 0 {
+			keys = append(keys, segment.key)
+		}
+	}
+	return keys
+}
+
+func (p YAMLPath) withKey(key string) YAMLPath {
+	return append(p[:len(p):len(p)], yamlPathSegment{key: key, index: -1})
+}
 
-		next := node.Content[i+1]
-		logger.Debug("closestNodeInArray:next", zap.Any("child", child), zap.Any("next", next))
-		if isAfterCursor(node.Style, next, pos) {
-			logger.Debug("closestNodeInArray:found", zap.Any("child", child))
-			return child
+func (p YAMLPath) withIndex(index int) YAMLPath {
+	return append(p[:len(p):len(p)], yamlPathSegment{index: index})
+}
+
+// nodeAt descends from node to the deepest node whose span includes pos, and returns it along with its path, relative to path.
+// Children of block-style collections span every line until the next child, children of flow-style collections span every character until the next child.
+func nodeAt(node *yaml.Node, pos protocol.Position, path YAMLPath) (YAMLPath, *yaml.Node) {
+	logger.Debug("nodeAt", zap.Stringer("path", path), zap.Any("pos", pos))
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if i == 0 && positionOf(key).Line > pos.Line {
+				return path, node
+			}
+
+			if i+2 >= len(node.Content) || isAfterCursor(node.Style, node.Content[i+2], pos) {
+				return nodeAt(value, pos, path.withKey(key.Value))
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if i+1 >= len(node.Content) || isAfterCursor(node.Style, node.Content[i+1], pos) {
+				return nodeAt(child, pos, path.withIndex(i))
+			}
 		}
 	}
-	return nil
+	return path, node
 }